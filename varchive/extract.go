@@ -0,0 +1,657 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package varchive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrUnknownFormat the format of the archive is not recognized.
+	ErrUnknownFormat = errors.New("unknown archive format")
+	// ErrExist the file to extract exists, see OverwriteError.
+	ErrExist = fmt.Errorf("archive entry %w", os.ErrExist)
+)
+
+// Format the format of an archive.
+type Format int
+
+const (
+	FormatUnknown Format = iota
+	FormatZip
+	FormatTar
+	FormatGzip
+	FormatBzip2
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatZip:
+		return "zip"
+	case FormatTar:
+		return "tar"
+	case FormatGzip:
+		return "gzip"
+	case FormatBzip2:
+		return "bzip2"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	tarMagicOffset = 257
+	sniffLen       = tarMagicOffset + 8
+)
+
+var (
+	zipMagic      = []byte("PK\x03\x04")
+	zipEmptyMagic = []byte("PK\x05\x06")
+	gzipMagic     = []byte{0x1f, 0x8b}
+	bzip2Magic    = []byte("BZh")
+	tarMagic      = []byte("ustar")
+)
+
+// DetectFormat detect the format by the magic bytes of the header of an archive.
+func DetectFormat(header []byte) Format {
+	switch {
+	case bytes.HasPrefix(header, zipMagic), bytes.HasPrefix(header, zipEmptyMagic):
+		return FormatZip
+	case bytes.HasPrefix(header, gzipMagic):
+		return FormatGzip
+	case bytes.HasPrefix(header, bzip2Magic):
+		return FormatBzip2
+	case len(header) >= tarMagicOffset+len(tarMagic) &&
+		bytes.Equal(header[tarMagicOffset:tarMagicOffset+len(tarMagic)], tarMagic):
+		return FormatTar
+	default:
+		return FormatUnknown
+	}
+}
+
+// Overwrite the policy when the file to extract exists.
+type Overwrite int
+
+const (
+	// OverwriteAlways replace the existing file.
+	OverwriteAlways Overwrite = iota
+	// OverwriteSkip keep the existing file and skip the entry.
+	OverwriteSkip
+	// OverwriteError stop extracting with an error wrapping ErrExist.
+	OverwriteError
+)
+
+// Options the options of extracting archives, which are shared by all formats.
+type Options struct {
+	// MaxFileSize the max size of a single file, no limit if it's not positive.
+	MaxFileSize int64
+	// MaxTotalSize the max size of all extracted files, no limit if it's not positive.
+	MaxTotalSize int64
+	// MaxEntries the max count of the entries in the archive, no limit if it's not positive.
+	MaxEntries int
+	// MaxCompressionRatio the max ratio of the uncompressed size to the compressed size of an entry,
+	// no limit if it's not positive. It only applies to the formats compressing entries separately, e.g. zip.
+	MaxCompressionRatio float64
+	// Filter returns whether to extract the entry, the name is slash separated after stripping components.
+	Filter func(name string) bool
+	// Overwrite the policy when the file to extract exists, default OverwriteAlways.
+	Overwrite Overwrite
+	// StripComponents strip the number of leading elements from the entry names,
+	// and the entries with no more elements are skipped.
+	StripComponents int
+	// PreserveMode keep the permissions of the entries, otherwise 0o644 for files and 0o755 for directories.
+	PreserveMode bool
+	// PreserveOwner keep the uid and gid of the entries, which usually requires the root privilege.
+	PreserveOwner bool
+}
+
+// Extract extract the archive to destDir, the format is detected by the content,
+// and tar archives compressed by gzip or bzip2 are supported.
+func Extract(src, destDir string, opts *Options) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	header := make([]byte, sniffLen)
+
+	n, err := io.ReadFull(f, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	if DetectFormat(header[:n]) == FormatZip {
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+
+		return ExtractZip(f, info.Size(), destDir, opts)
+	}
+
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	return ExtractReader(f, destDir, opts)
+}
+
+// ExtractReader extract the archive stream to destDir, the format is detected by the content.
+// Zip archives are not supported as they're not streamable, use ExtractZip instead.
+func ExtractReader(r io.Reader, destDir string, opts *Options) error {
+	r, format, err := Decompress(r)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatZip:
+		return fmt.Errorf("%w: zip stream, use ExtractZip instead", ErrUnknownFormat)
+	case FormatUnknown:
+		return ErrUnknownFormat
+	default:
+		// gzip and bzip2 are assumed to contain a tar archive.
+		return ExtractTar(r, destDir, opts)
+	}
+}
+
+// Decompress returns a reader decompressing r if it's compressed by gzip or bzip2, and the detected format.
+func Decompress(r io.Reader) (io.Reader, Format, error) {
+	br := bufio.NewReaderSize(r, max(sniffLen, 4096))
+
+	header, err := br.Peek(sniffLen)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, FormatUnknown, err
+	}
+
+	format := DetectFormat(header)
+
+	switch format {
+	case FormatGzip:
+		gr, err := gzip.NewReader(br)
+		return gr, format, err
+	case FormatBzip2:
+		return bzip2.NewReader(br), format, nil
+	default:
+		return br, format, nil
+	}
+}
+
+// ExtractTar extract the tar stream to destDir.
+func ExtractTar(r io.Reader, destDir string, opts *Options) error {
+	x, err := newExtractor(destDir, opts)
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(r)
+
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err = x.extract(tarEntry(header, tr)); err != nil {
+			return err
+		}
+	}
+
+	return x.finish()
+}
+
+// ExtractZip extract the zip archive of the size to destDir.
+func ExtractZip(r io.ReaderAt, size int64, destDir string, opts *Options) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+
+	return ExtractZipFiles(zr.File, destDir, opts)
+}
+
+// ExtractZipFiles extract the files of a zip archive to destDir.
+func ExtractZipFiles(files []*zip.File, destDir string, opts *Options) error {
+	x, err := newExtractor(destDir, opts)
+	if err != nil {
+		return err
+	}
+
+	if x.opts.MaxEntries > 0 && len(files) > x.opts.MaxEntries {
+		return fmt.Errorf("%w: entry count %d exceeds %d", ErrLimitExceeded, len(files), x.opts.MaxEntries)
+	}
+
+	for _, f := range files {
+		e, err := zipEntry(f)
+		if err != nil {
+			return err
+		}
+
+		if err = x.extract(e); err != nil {
+			return err
+		}
+	}
+
+	return x.finish()
+}
+
+func tarEntry(header *tar.Header, r io.Reader) *entry {
+	e := &entry{
+		compressedSize: -1,
+		name:           header.Name,
+		mode:           header.FileInfo().Mode().Perm(),
+		modTime:        header.ModTime,
+		uid:            header.Uid,
+		gid:            header.Gid,
+		size:           header.Size,
+		linkname:       header.Linkname,
+		open: func() (io.ReadCloser, error) {
+			return io.NopCloser(r), nil
+		},
+	}
+
+	switch header.Typeflag {
+	case tar.TypeDir:
+		e.kind = kindDir
+	case tar.TypeReg:
+		e.kind = kindFile
+	case tar.TypeSymlink:
+		e.kind = kindSymlink
+	case tar.TypeLink:
+		e.kind = kindLink
+	default:
+		e.kind = kindOther
+	}
+
+	return e
+}
+
+// maxZipLinkSize the max size of the target of a symlink in zip archives.
+const maxZipLinkSize = 4096
+
+func zipEntry(f *zip.File) (*entry, error) {
+	mode := f.Mode()
+
+	e := &entry{
+		name:           f.Name,
+		mode:           mode.Perm(),
+		modTime:        f.Modified,
+		uid:            -1,
+		gid:            -1,
+		size:           int64(f.UncompressedSize64),
+		compressedSize: int64(f.CompressedSize64),
+		open:           f.Open,
+	}
+
+	switch {
+	case mode.IsDir():
+		e.kind = kindDir
+	case mode&os.ModeSymlink != 0:
+		e.kind = kindSymlink
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+
+		target, err := io.ReadAll(io.LimitReader(rc, maxZipLinkSize))
+		_ = rc.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		e.linkname = string(target)
+	case mode.IsRegular():
+		e.kind = kindFile
+	default:
+		e.kind = kindOther
+	}
+
+	return e, nil
+}
+
+type entryKind int
+
+const (
+	kindOther entryKind = iota
+	kindDir
+	kindFile
+	kindSymlink
+	kindLink
+)
+
+// entry an archive entry of any format.
+type entry struct {
+	name     string
+	kind     entryKind
+	mode     os.FileMode
+	modTime  time.Time
+	uid, gid int
+	size     int64
+	linkname string
+	open     func() (io.ReadCloser, error)
+
+	// compressedSize the compressed size of the entry, -1 if unknown.
+	compressedSize int64
+}
+
+type extractedDir struct {
+	path    string
+	mode    os.FileMode
+	modTime time.Time
+}
+
+// extractor extract entries of any format.
+type extractor struct {
+	destDir string
+	opts    Options
+	total   int64
+	entries int
+	dirs    []extractedDir
+}
+
+func newExtractor(destDir string, opts *Options) (*extractor, error) {
+	x := &extractor{destDir: destDir}
+	if opts != nil {
+		x.opts = *opts
+	}
+
+	if err := os.MkdirAll(destDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	return x, nil
+}
+
+// stripName returns the name after stripping the leading components, false if nothing left.
+func (x *extractor) stripName(name string) (string, bool) {
+	// the leading separators are removed as SafePath.
+	name = strings.TrimLeft(strings.ReplaceAll(name, `\`, "/"), "/")
+
+	for range x.opts.StripComponents {
+		_, rest, found := strings.Cut(name, "/")
+		if !found {
+			return "", false
+		}
+
+		name = rest
+	}
+
+	name = strings.TrimSuffix(name, "/")
+
+	return name, name != "" && name != "."
+}
+
+func (x *extractor) extract(e *entry) error {
+	x.entries++
+
+	if x.opts.MaxEntries > 0 && x.entries > x.opts.MaxEntries {
+		return fmt.Errorf("%w: entry count exceeds %d", ErrLimitExceeded, x.opts.MaxEntries)
+	}
+
+	name, ok := x.stripName(e.name)
+	if !ok {
+		return nil
+	}
+
+	if x.opts.Filter != nil && !x.opts.Filter(name) {
+		return nil
+	}
+
+	target, err := SafePath(x.destDir, name)
+	if err != nil {
+		return err
+	}
+
+	if e.kind == kindDir {
+		return x.extractDir(e, target)
+	}
+
+	if e.kind == kindOther {
+		// devices, fifos and the others are ignored.
+		return nil
+	}
+
+	if skip, err := x.checkExisting(target, name); skip || err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return err
+	}
+
+	switch e.kind {
+	case kindSymlink:
+		if err = SafeLinkTarget(x.destDir, target, e.linkname); err != nil {
+			return err
+		}
+
+		// the mtime of a symlink is not restored, which would change the target.
+		if err = os.Symlink(e.linkname, target); err != nil {
+			return err
+		}
+
+		return x.chown(target, e, true)
+	case kindLink:
+		linkName, ok := x.stripName(e.linkname)
+		if !ok {
+			return fmt.Errorf("%w: hard link %s to %s", ErrUnsafePath, e.name, e.linkname)
+		}
+
+		linkPath, err := SafePath(x.destDir, linkName)
+		if err != nil {
+			return err
+		}
+
+		return os.Link(linkPath, target)
+	default:
+		return x.extractFile(e, target, name)
+	}
+}
+
+func (x *extractor) extractDir(e *entry, target string) error {
+	if err := os.MkdirAll(target, os.ModePerm); err != nil {
+		return err
+	}
+
+	if err := x.chown(target, e, false); err != nil {
+		return err
+	}
+
+	// the permissions and mtimes of directories are restored at last,
+	// as creating files in them changes the mtimes, and the permissions may not allow writing.
+	x.dirs = append(x.dirs, extractedDir{path: target, mode: x.perm(e), modTime: e.modTime})
+
+	return nil
+}
+
+// checkExisting apply the overwrite policy, returns whether to skip the entry.
+func (x *extractor) checkExisting(target, name string) (bool, error) {
+	info, err := os.Lstat(target)
+	if err != nil {
+		return false, nil
+	}
+
+	switch x.opts.Overwrite {
+	case OverwriteSkip:
+		return true, nil
+	case OverwriteError:
+		return false, fmt.Errorf("%w: %s", ErrExist, name)
+	case OverwriteAlways:
+	}
+
+	if info.IsDir() {
+		return false, fmt.Errorf("%w: %s is a directory", ErrExist, name)
+	}
+
+	// remove the existing one, which may be a symlink.
+	return false, os.Remove(target)
+}
+
+// ratioFreeSize the size under which the compression ratio is not limited,
+// as small files of repeated content are compressed in high ratios too.
+const ratioFreeSize = 64 * 1024
+
+// fileLimit returns the max size to extract the entry, which is negative if no limit,
+// and the error if the limit is exceeded.
+func (x *extractor) fileLimit(e *entry, name string) (int64, error) {
+	limit := int64(-1)
+
+	var limitErr error
+
+	apply := func(l int64, err error) {
+		if limit < 0 || l < limit {
+			limit, limitErr = l, err
+		}
+	}
+
+	if x.opts.MaxFileSize > 0 {
+		apply(x.opts.MaxFileSize, fmt.Errorf("%w: file %s size exceeds %d", ErrLimitExceeded, name, x.opts.MaxFileSize))
+	}
+
+	if x.opts.MaxTotalSize > 0 {
+		apply(x.opts.MaxTotalSize-x.total,
+			fmt.Errorf("%w: total size exceeds %d at %s", ErrLimitExceeded, x.opts.MaxTotalSize, name))
+	}
+
+	if x.opts.MaxCompressionRatio > 0 && e.compressedSize >= 0 {
+		apply(max(int64(float64(e.compressedSize)*x.opts.MaxCompressionRatio), ratioFreeSize),
+			fmt.Errorf("%w: file %s compression ratio exceeds %g", ErrLimitExceeded, name, x.opts.MaxCompressionRatio))
+	}
+
+	return limit, limitErr
+}
+
+func (x *extractor) extractFile(e *entry, target, name string) (err error) {
+	// the declared size is checked first, and the extracted size is checked again as it may be forged.
+	limit, limitErr := x.fileLimit(e, name)
+	if limit >= 0 && e.size > limit {
+		return limitErr
+	}
+
+	rc, err := e.open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	outFile, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, x.perm(e))
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = rc
+	if limit >= 0 {
+		r = io.LimitReader(rc, limit+1)
+	}
+
+	n, err := io.Copy(outFile, r)
+
+	if closeErr := outFile.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if limit >= 0 && n > limit {
+		_ = os.Remove(target)
+		return limitErr
+	}
+
+	x.total += n
+
+	// the permission passed to OpenFile is masked by umask.
+	if x.opts.PreserveMode {
+		if err = os.Chmod(target, e.mode); err != nil {
+			return err
+		}
+	}
+
+	if err = x.chown(target, e, false); err != nil {
+		return err
+	}
+
+	return os.Chtimes(target, time.Time{}, e.modTime)
+}
+
+func (x *extractor) perm(e *entry) os.FileMode {
+	if x.opts.PreserveMode {
+		return e.mode
+	}
+
+	if e.kind == kindDir {
+		return 0o755
+	}
+
+	return 0o644
+}
+
+func (x *extractor) chown(target string, e *entry, link bool) error {
+	if !x.opts.PreserveOwner || e.uid < 0 || e.gid < 0 {
+		return nil
+	}
+
+	if link {
+		return os.Lchown(target, e.uid, e.gid)
+	}
+
+	return os.Chown(target, e.uid, e.gid)
+}
+
+// finish restore the permissions and mtimes of the directories.
+func (x *extractor) finish() error {
+	for i := len(x.dirs) - 1; i >= 0; i-- {
+		d := x.dirs[i]
+
+		if x.opts.PreserveMode {
+			if err := os.Chmod(d.path, d.mode); err != nil {
+				return err
+			}
+		}
+
+		if err := os.Chtimes(d.path, time.Time{}, d.modTime); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
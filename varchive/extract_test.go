@@ -0,0 +1,140 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package varchive_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/varchive"
+	"github.com/vogo/vogo/vio/vioutil"
+)
+
+var testFiles = []struct {
+	name, content string
+}{
+	{"pkg/a.txt", "aaa"},
+	{"pkg/b/b.txt", "bbb"},
+	{"pkg/c.log", "ccc"},
+}
+
+func writeTestArchive(t *testing.T, path string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	switch {
+	case strings.HasSuffix(path, ".zip"):
+		zw := zip.NewWriter(&buf)
+
+		for _, f := range testFiles {
+			w, err := zw.Create(f.name)
+			assert.NoError(t, err)
+
+			_, err = w.Write([]byte(f.content))
+			assert.NoError(t, err)
+		}
+
+		assert.NoError(t, zw.Close())
+	default:
+		gw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gw)
+
+		for _, f := range testFiles {
+			assert.NoError(t, tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0o600, Size: int64(len(f.content))}))
+
+			_, err := tw.Write([]byte(f.content))
+			assert.NoError(t, err)
+		}
+
+		assert.NoError(t, tw.Close())
+		assert.NoError(t, gw.Close())
+	}
+
+	assert.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+}
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+
+	for _, name := range []string{"test.zip", "test.tar.gz"} {
+		src := filepath.Join(workDir, name)
+		writeTestArchive(t, src)
+
+		destDir := filepath.Join(workDir, "output-"+name)
+
+		assert.NoError(t, varchive.Extract(src, destDir, &varchive.Options{
+			StripComponents: 1,
+			Filter: func(name string) bool {
+				return strings.HasSuffix(name, ".txt")
+			},
+		}))
+
+		assert.Equal(t, "aaa", vioutil.ReadFile(filepath.Join(destDir, "a.txt")))
+		assert.Equal(t, "bbb", vioutil.ReadFile(filepath.Join(destDir, "b", "b.txt")))
+		assert.False(t, vioutil.ExistFile(filepath.Join(destDir, "c.log")))
+
+		assert.NoError(t, os.WriteFile(filepath.Join(destDir, "a.txt"), []byte("old"), 0o600))
+
+		assert.NoError(t, varchive.Extract(src, destDir, &varchive.Options{StripComponents: 1, Overwrite: varchive.OverwriteSkip}))
+		assert.Equal(t, "old", vioutil.ReadFile(filepath.Join(destDir, "a.txt")))
+		assert.Equal(t, "ccc", vioutil.ReadFile(filepath.Join(destDir, "c.log")))
+
+		err := varchive.Extract(src, destDir, &varchive.Options{StripComponents: 1, Overwrite: varchive.OverwriteError})
+		assert.ErrorIs(t, err, os.ErrExist)
+
+		assert.NoError(t, varchive.Extract(src, destDir, &varchive.Options{StripComponents: 1}))
+		assert.Equal(t, "aaa", vioutil.ReadFile(filepath.Join(destDir, "a.txt")))
+
+		err = varchive.Extract(src, t.TempDir(), &varchive.Options{MaxTotalSize: 5})
+		assert.ErrorIs(t, err, varchive.ErrLimitExceeded)
+
+		err = varchive.Extract(src, t.TempDir(), &varchive.Options{MaxFileSize: 2})
+		assert.ErrorIs(t, err, varchive.ErrLimitExceeded)
+	}
+
+	unknown := filepath.Join(workDir, "unknown")
+	assert.NoError(t, os.WriteFile(unknown, []byte("not an archive"), 0o600))
+	assert.ErrorIs(t, varchive.Extract(unknown, t.TempDir(), nil), varchive.ErrUnknownFormat)
+}
+
+func TestDetectFormat(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, varchive.FormatZip, varchive.DetectFormat([]byte("PK\x03\x04....")))
+	assert.Equal(t, varchive.FormatGzip, varchive.DetectFormat([]byte{0x1f, 0x8b, 0x08}))
+	assert.Equal(t, varchive.FormatBzip2, varchive.DetectFormat([]byte("BZh91AY")))
+	assert.Equal(t, varchive.FormatUnknown, varchive.DetectFormat([]byte("hello")))
+
+	var buf bytes.Buffer
+
+	tw := tar.NewWriter(&buf)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "a", Typeflag: tar.TypeDir}))
+	assert.NoError(t, tw.Close())
+	assert.Equal(t, varchive.FormatTar, varchive.DetectFormat(buf.Bytes()))
+	assert.Equal(t, "tar", varchive.FormatTar.String())
+}
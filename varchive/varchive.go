@@ -0,0 +1,164 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package varchive provides the common utilities of archives, see the sub packages for the formats.
+package varchive
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// ErrUnsafePath the entry path escapes the destination directory.
+	ErrUnsafePath = errors.New("unsafe archive entry path")
+	// ErrLimitExceeded the archive exceeds a limit of extraction.
+	ErrLimitExceeded = errors.New("archive limit exceeded")
+)
+
+// SafePath returns the path of the archive entry under destDir, or ErrUnsafePath if it escapes destDir
+// by ".." elements, a volume name, or an existing symlink under destDir pointing outside.
+// Both "/" and "\" are treated as separators of the entry name, and the leading separators are removed
+// like tar and unzip, e.g. "/a.txt" written by the early versions of vzip.AddDirToZip is extracted to destDir/a.txt.
+func SafePath(destDir, name string) (string, error) {
+	slashed := strings.TrimLeft(strings.ReplaceAll(name, `\`, "/"), "/")
+
+	if !filepath.IsLocal(filepath.FromSlash(slashed)) {
+		return "", fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+
+	target := filepath.Join(destDir, filepath.FromSlash(slashed))
+
+	if err := checkSymlinks(destDir, target); err != nil {
+		return "", fmt.Errorf("%w: %s", err, name)
+	}
+
+	return target, nil
+}
+
+// checkSymlinks checks the existing symlinks from destDir to target don't point outside destDir.
+func checkSymlinks(destDir, target string) error {
+	rel, err := filepath.Rel(destDir, target)
+	if err != nil {
+		return ErrUnsafePath
+	}
+
+	var realDest string
+
+	p := destDir
+
+	for _, elem := range strings.Split(rel, string(filepath.Separator)) {
+		p = filepath.Join(p, elem)
+
+		info, err := os.Lstat(p)
+		if err != nil {
+			// the rest don't exist.
+			return nil
+		}
+
+		if info.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+
+		if realDest == "" {
+			if realDest, err = filepath.EvalSymlinks(destDir); err != nil {
+				return err
+			}
+		}
+
+		resolved, err := filepath.EvalSymlinks(p)
+		if err != nil || !Within(realDest, resolved) {
+			return ErrUnsafePath
+		}
+	}
+
+	return nil
+}
+
+// Within returns whether the path is dir or under dir.
+func Within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+
+	return err == nil && filepath.IsLocal(rel)
+}
+
+// SafeLinkTarget check the target of a symlink entry stays under destDir, where linkPath is the path of the symlink.
+// The dir of the symlink is resolved through the existing symlinks, and a ".." element is only allowed
+// at the beginning of the target, as "d/.." may climb out of destDir if d is a symlink to ".".
+func SafeLinkTarget(destDir, linkPath, target string) error {
+	unsafe := fmt.Errorf("%w: symlink %s to %s", ErrUnsafePath, linkPath, target)
+
+	slashed := filepath.ToSlash(target)
+	if filepath.IsAbs(target) || strings.HasPrefix(slashed, "/") {
+		return unsafe
+	}
+
+	realDest, err := resolvePath(destDir)
+	if err != nil {
+		return err
+	}
+
+	p, err := resolvePath(filepath.Dir(linkPath))
+	if err != nil {
+		return err
+	}
+
+	named := false
+
+	for _, elem := range strings.Split(slashed, "/") {
+		switch elem {
+		case "", ".":
+		case "..":
+			if named {
+				return unsafe
+			}
+
+			p = filepath.Dir(p)
+		default:
+			named = true
+			p = filepath.Join(p, elem)
+		}
+	}
+
+	if !Within(realDest, p) {
+		return unsafe
+	}
+
+	return nil
+}
+
+// resolvePath returns the path with the symlinks of the longest existing prefix evaluated.
+func resolvePath(path string) (string, error) {
+	for p := path; ; p = filepath.Dir(p) {
+		resolved, err := filepath.EvalSymlinks(p)
+		if err == nil {
+			rel, err := filepath.Rel(p, path)
+			if err != nil {
+				return "", err
+			}
+
+			return filepath.Join(resolved, rel), nil
+		}
+
+		if !os.IsNotExist(err) || filepath.Dir(p) == p {
+			return "", err
+		}
+	}
+}
@@ -0,0 +1,72 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package varchive_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/varchive"
+)
+
+func TestSafePath(t *testing.T) {
+	t.Parallel()
+
+	destDir := t.TempDir()
+	outside := t.TempDir()
+
+	p, err := varchive.SafePath(destDir, "a/a..b.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(destDir, "a", "a..b.txt"), p)
+
+	_, err = varchive.SafePath(destDir, "a/./b/../c.txt")
+	assert.NoError(t, err)
+
+	// the leading separators are removed.
+	for _, name := range []string{"/etc/passwd", `\etc\passwd`, "//etc/passwd"} {
+		p, err = varchive.SafePath(destDir, name)
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(destDir, "etc", "passwd"), p)
+	}
+
+	for _, name := range []string{"", "/", "../a.txt", "/../a.txt", "a/../../b.txt", `..\a.txt`} {
+		_, err = varchive.SafePath(destDir, name)
+		assert.ErrorIs(t, err, varchive.ErrUnsafePath, name)
+	}
+
+	assert.NoError(t, os.Symlink(outside, filepath.Join(destDir, "out")))
+	assert.NoError(t, os.Symlink(".", filepath.Join(destDir, "in")))
+
+	_, err = varchive.SafePath(destDir, "out/a.txt")
+	assert.ErrorIs(t, err, varchive.ErrUnsafePath)
+
+	_, err = varchive.SafePath(destDir, "in/a.txt")
+	assert.NoError(t, err)
+
+	link := filepath.Join(destDir, "a", "link")
+	assert.NoError(t, varchive.SafeLinkTarget(destDir, link, "../b.txt"))
+	assert.ErrorIs(t, varchive.SafeLinkTarget(destDir, link, "../../b.txt"), varchive.ErrUnsafePath)
+	assert.ErrorIs(t, varchive.SafeLinkTarget(destDir, link, "/etc/passwd"), varchive.ErrUnsafePath)
+
+	// "in" links to destDir.
+	assert.NoError(t, varchive.SafeLinkTarget(destDir, link, "../in/b.txt"))
+	assert.ErrorIs(t, varchive.SafeLinkTarget(destDir, filepath.Join(destDir, "e"), "in/.."), varchive.ErrUnsafePath)
+	assert.ErrorIs(t, varchive.SafeLinkTarget(destDir, filepath.Join(destDir, "in", "e"), ".."), varchive.ErrUnsafePath)
+}
@@ -0,0 +1,195 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package vtar provides the utilities of tar archives, which may be compressed by gzip or bzip2 (read only).
+package vtar
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vogo/vogo/varchive"
+)
+
+const (
+	DefaultTarLimitSize = 8 * 1024 * 1024
+
+	// DefaultTarLimitTotalSize the default max size of all files extracted by Untar and LimitUntar.
+	DefaultTarLimitTotalSize = 1024 * 1024 * 1024
+	// DefaultTarMaxEntries the default max count of the entries extracted by Untar and LimitUntar.
+	DefaultTarMaxEntries = 100000
+)
+
+// Untar will decompress a tar archive, which may be compressed by gzip or bzip2,
+// moving all files and folders within the tar file (parameter 1) to an output directory (parameter 2).
+func Untar(src, destDir string) error {
+	return LimitUntar(src, destDir, DefaultTarLimitSize)
+}
+
+// LimitUntar decompress a tar archive, while limit the size of a single containing file.
+// The total size and the entry count are limited by the defaults too.
+// An error wrapping varchive.ErrLimitExceeded is returned if any limit is exceeded.
+func LimitUntar(src, destDir string, limitSize int64) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	return LimitUntarReader(f, destDir, limitSize)
+}
+
+// LimitUntarReader decompress a tar stream, which may be compressed by gzip or bzip2 detected by the magic bytes,
+// while limit the size of a single containing file, and the total size and the entry count by the defaults.
+// See varchive.ExtractTar for more options.
+func LimitUntarReader(r io.Reader, destDir string, limitSize int64) error {
+	r, err := Decompress(r)
+	if err != nil {
+		return err
+	}
+
+	opts := DefaultOptions()
+	opts.MaxFileSize = limitSize
+
+	return varchive.ExtractTar(r, destDir, opts)
+}
+
+// DefaultOptions returns the extraction options of Untar, which limit the file size, the total size
+// and the entry count to the defaults.
+func DefaultOptions() *varchive.Options {
+	return &varchive.Options{
+		MaxFileSize:  DefaultTarLimitSize,
+		MaxTotalSize: DefaultTarLimitTotalSize,
+		MaxEntries:   DefaultTarMaxEntries,
+		PreserveMode: true,
+	}
+}
+
+// Decompress returns a reader decompressing r if it's compressed by gzip or bzip2, detected by the magic bytes.
+func Decompress(r io.Reader) (io.Reader, error) {
+	r, _, err := varchive.Decompress(r)
+
+	return r, err
+}
+
+// TarDir compresses a directory into a tar archive file, which is compressed by gzip
+// if the path ends with ".gz" or ".tgz".
+func TarDir(tarPath, dir string) (err error) {
+	newTarFile, err := os.Create(tarPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := newTarFile.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	var w io.Writer = newTarFile
+
+	if strings.HasSuffix(tarPath, ".gz") || strings.HasSuffix(tarPath, ".tgz") {
+		gw := gzip.NewWriter(newTarFile)
+		defer func() {
+			if closeErr := gw.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}()
+
+		w = gw
+	}
+
+	tarWriter := tar.NewWriter(w)
+	defer func() {
+		if closeErr := tarWriter.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	baseDirLen := len(dir)
+	if dir[len(dir)-1] != '/' {
+		baseDirLen = len(filepath.Dir(dir))
+	}
+
+	return AddDirToTar(tarWriter, baseDirLen, dir)
+}
+
+// AddDirToTar add all files, directories and symlinks under the target directory into a tar file.
+func AddDirToTar(writer *tar.Writer, baseDirLen int, dir string) error {
+	return filepath.Walk(dir, func(path string, _ os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if dir == path {
+			return nil
+		}
+
+		return AddFileToTar(writer, path, strings.TrimPrefix(path[baseDirLen:], "/"))
+	})
+}
+
+// AddFileToTar add a single file, directory or symlink into a tar file, with the permission and mtime kept.
+func AddFileToTar(tarWriter *tar.Writer, filePath, pathInTar string) error {
+	info, err := os.Lstat(filePath)
+	if err != nil {
+		return err
+	}
+
+	var link string
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		if link, err = os.Readlink(filePath); err != nil {
+			return err
+		}
+	}
+
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+
+	header.Name = filepath.ToSlash(pathInTar)
+	if info.IsDir() {
+		header.Name += "/"
+	}
+
+	if err = tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	fileToTar, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = fileToTar.Close()
+	}()
+
+	_, err = io.Copy(tarWriter, fileToTar)
+
+	return err
+}
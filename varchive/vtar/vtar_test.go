@@ -0,0 +1,141 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vtar_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/varchive"
+	"github.com/vogo/vogo/varchive/vtar"
+	"github.com/vogo/vogo/vio/vioutil"
+)
+
+func TestTarDir(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(workDir, "a", "b"), os.ModePerm))
+	assert.NoError(t, os.MkdirAll(filepath.Join(workDir, "a", "empty"), os.ModePerm))
+	assert.NoError(t, os.WriteFile(filepath.Join(workDir, "a", "a1.txt"), []byte("aaa1"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(workDir, "a", "b", "b1.sh"), []byte("bbb1"), 0o755))
+	assert.NoError(t, os.Chtimes(filepath.Join(workDir, "a", "a1.txt"), mtime, mtime))
+	assert.NoError(t, os.Symlink("../a1.txt", filepath.Join(workDir, "a", "b", "link")))
+
+	for _, name := range []string{"test.tar", "test.tar.gz"} {
+		tarPath := filepath.Join(workDir, name)
+		outputDir := filepath.Join(workDir, "output-"+name)
+
+		assert.NoError(t, vtar.TarDir(tarPath, filepath.Join(workDir, "a")))
+		assert.NoError(t, vtar.Untar(tarPath, outputDir))
+
+		assert.Equal(t, "aaa1", vioutil.ReadFile(filepath.Join(outputDir, "a", "a1.txt")))
+		assert.Equal(t, "aaa1", vioutil.ReadFile(filepath.Join(outputDir, "a", "b", "link")))
+		assert.True(t, vioutil.ExistDir(filepath.Join(outputDir, "a", "empty")))
+
+		info, err := os.Stat(filepath.Join(outputDir, "a", "b", "b1.sh"))
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+
+		info, err = os.Stat(filepath.Join(outputDir, "a", "a1.txt"))
+		assert.NoError(t, err)
+		assert.True(t, mtime.Equal(info.ModTime()))
+	}
+}
+
+func TestUntarUnsafe(t *testing.T) {
+	t.Parallel()
+
+	for _, headers := range [][]*tar.Header{
+		{{Name: "../evil.txt", Typeflag: tar.TypeReg}},
+		{{Name: "/../evil.txt", Typeflag: tar.TypeReg}},
+		{{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "../../etc/passwd"}},
+		{{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"}},
+		{{Name: "hard", Typeflag: tar.TypeLink, Linkname: "../evil.txt"}},
+		// climb out through a symlink to the dest dir.
+		{
+			{Name: "d", Typeflag: tar.TypeSymlink, Linkname: "."},
+			{Name: "e", Typeflag: tar.TypeSymlink, Linkname: "d/.."},
+		},
+		{
+			{Name: "d", Typeflag: tar.TypeSymlink, Linkname: "."},
+			{Name: "d/e", Typeflag: tar.TypeSymlink, Linkname: ".."},
+		},
+	} {
+		var buf bytes.Buffer
+
+		tw := tar.NewWriter(&buf)
+
+		for _, header := range headers {
+			assert.NoError(t, tw.WriteHeader(header))
+		}
+
+		assert.NoError(t, tw.Close())
+
+		last := headers[len(headers)-1]
+		err := vtar.LimitUntarReader(&buf, t.TempDir(), vtar.DefaultTarLimitSize)
+		assert.ErrorIs(t, err, varchive.ErrUnsafePath, last.Name+" -> "+last.Linkname)
+	}
+
+	var buf bytes.Buffer
+
+	tw := tar.NewWriter(&buf)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "big.txt", Typeflag: tar.TypeReg, Size: 10, Mode: 0o600}))
+	_, err := tw.Write(make([]byte, 10))
+	assert.NoError(t, err)
+	assert.NoError(t, tw.Close())
+
+	assert.ErrorIs(t, vtar.LimitUntarReader(&buf, t.TempDir(), 5), varchive.ErrLimitExceeded)
+}
+
+func TestUntarDefaultLimits(t *testing.T) {
+	t.Parallel()
+
+	opts := vtar.DefaultOptions()
+	assert.Equal(t, int64(vtar.DefaultTarLimitSize), opts.MaxFileSize)
+	assert.Equal(t, int64(vtar.DefaultTarLimitTotalSize), opts.MaxTotalSize)
+	assert.Equal(t, vtar.DefaultTarMaxEntries, opts.MaxEntries)
+
+	r, w := io.Pipe()
+
+	go func() {
+		tw := tar.NewWriter(w)
+
+		// stop writing once the extraction fails.
+		for i := 0; i <= vtar.DefaultTarMaxEntries; i++ {
+			if err := tw.WriteHeader(&tar.Header{Name: "a/", Typeflag: tar.TypeDir, Mode: 0o755}); err != nil {
+				return
+			}
+		}
+
+		_ = w.CloseWithError(tw.Close())
+	}()
+
+	err := vtar.LimitUntarReader(r, t.TempDir(), vtar.DefaultTarLimitSize)
+	_ = r.Close()
+
+	assert.ErrorIs(t, err, varchive.ErrLimitExceeded)
+}
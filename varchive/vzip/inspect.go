@@ -0,0 +1,247 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vzip
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/vogo/vogo/varchive"
+)
+
+// ErrEntryNotFound the entry is not found in the archive.
+var ErrEntryNotFound = fmt.Errorf("zip entry %w", fs.ErrNotExist)
+
+// Entry the metadata of an entry in a zip archive.
+type Entry struct {
+	Name           string
+	Size           int64
+	CompressedSize int64
+	Modified       time.Time
+	Mode           os.FileMode
+	CRC32          uint32
+	Method         uint16
+	Comment        string
+}
+
+// IsDir returns whether the entry is a directory.
+func (e *Entry) IsDir() bool {
+	return e.Mode.IsDir()
+}
+
+func newEntry(f *zip.File) Entry {
+	return Entry{
+		Name:           f.Name,
+		Size:           int64(f.UncompressedSize64),
+		CompressedSize: int64(f.CompressedSize64),
+		Modified:       f.Modified,
+		Mode:           f.Mode(),
+		CRC32:          f.CRC32,
+		Method:         f.Method,
+		Comment:        f.Comment,
+	}
+}
+
+// List returns the entries of a zip archive.
+func List(src string) ([]Entry, error) {
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	return listFiles(r.File), nil
+}
+
+// ListReader returns the entries of a zip archive of the size read from r,
+// which may be a file, a memory buffer or a remote object read by range requests.
+func ListReader(r io.ReaderAt, size int64) ([]Entry, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return listFiles(zr.File), nil
+}
+
+func listFiles(files []*zip.File) []Entry {
+	entries := make([]Entry, len(files))
+	for i, f := range files {
+		entries[i] = newEntry(f)
+	}
+
+	return entries
+}
+
+type entryReadCloser struct {
+	io.ReadCloser
+	archive io.Closer
+}
+
+func (r *entryReadCloser) Close() error {
+	err := r.ReadCloser.Close()
+
+	if closeErr := r.archive.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// OpenEntry open the entry of the name in a zip archive without extracting others,
+// the checksum is verified when reading to the end.
+func OpenEntry(src, name string) (io.ReadCloser, error) {
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := openFile(r.File, name)
+	if err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+
+	return &entryReadCloser{ReadCloser: rc, archive: r}, nil
+}
+
+// OpenEntryReader open the entry of the name in a zip archive of the size read from r.
+func OpenEntryReader(r io.ReaderAt, size int64, name string) (io.ReadCloser, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return openFile(zr.File, name)
+}
+
+func openFile(files []*zip.File, name string) (io.ReadCloser, error) {
+	for _, f := range files {
+		if f.Name == name {
+			return f.Open()
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrEntryNotFound, name)
+}
+
+// ExtractGlob extract the entries matching any of the glob patterns from a zip archive to destDir.
+// A pattern without "/" matches the base name, otherwise the slash separated entry name after stripping components.
+// The limits of DefaultOptions are applied if opts is nil, and path.ErrBadPattern is returned for a malformed pattern.
+func ExtractGlob(src, destDir string, patterns []string, opts *varchive.Options) error {
+	globOpts, err := globOptions(patterns, opts)
+	if err != nil {
+		return err
+	}
+
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	return varchive.ExtractZipFiles(r.File, destDir, globOpts)
+}
+
+// ExtractGlobReader extract the entries matching any of the glob patterns from a zip archive of the size read from r.
+// See ExtractGlob for the patterns and the options.
+func ExtractGlobReader(r io.ReaderAt, size int64, destDir string, patterns []string, opts *varchive.Options) error {
+	globOpts, err := globOptions(patterns, opts)
+	if err != nil {
+		return err
+	}
+
+	return varchive.ExtractZip(r, size, destDir, globOpts)
+}
+
+// globOptions returns a copy of the options filtering entries by the patterns, DefaultOptions if opts is nil.
+func globOptions(patterns []string, opts *varchive.Options) (*varchive.Options, error) {
+	if err := checkPatterns(patterns); err != nil {
+		return nil, err
+	}
+
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	o := *opts
+
+	filter := o.Filter
+
+	o.Filter = func(name string) bool {
+		return matchAny(patterns, name) && (filter == nil || filter(name))
+	}
+
+	return &o, nil
+}
+
+// Verify read all entries of a zip archive to verify their checksums without extracting,
+// returns the error of the first corrupted entry.
+func Verify(src string) error {
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	return verifyFiles(r.File)
+}
+
+// VerifyReader verify the checksums of the entries of a zip archive of the size read from r.
+func VerifyReader(r io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+
+	return verifyFiles(zr.File)
+}
+
+func verifyFiles(files []*zip.File) error {
+	for _, f := range files {
+		if err := verifyFile(f); err != nil {
+			return fmt.Errorf("verify %s: %w", f.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func verifyFile(f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	_, err = io.Copy(io.Discard, rc)
+
+	return err
+}
@@ -0,0 +1,137 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vzip_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/varchive"
+	"github.com/vogo/vogo/varchive/vzip"
+	"github.com/vogo/vogo/vio/vioutil"
+)
+
+func TestInspect(t *testing.T) {
+	t.Parallel()
+
+	root := writeTestDir(t)
+	zipPath := filepath.Join(t.TempDir(), "test.zip")
+
+	f, err := os.Create(zipPath)
+	assert.NoError(t, err)
+	assert.NoError(t, vzip.Write(f, root, nil))
+	assert.NoError(t, f.Close())
+
+	entries, err := vzip.List(zipPath)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 9)
+	assert.Equal(t, "a.txt", entries[0].Name)
+	assert.Equal(t, int64(1000), entries[0].Size)
+	assert.Less(t, entries[0].CompressedSize, int64(1000))
+	assert.True(t, entries[1].IsDir())
+
+	rc, err := vzip.OpenEntry(zipPath, "b/c/c.txt")
+	assert.NoError(t, err)
+
+	data, err := io.ReadAll(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, "ccc", string(data))
+	assert.NoError(t, rc.Close())
+
+	_, err = vzip.OpenEntry(zipPath, "none")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	destDir := t.TempDir()
+	assert.NoError(t, vzip.ExtractGlob(zipPath, destDir, []string{"*.log", "tmp/*"}, nil))
+	assert.Equal(t, "bbb", vioutil.ReadFile(filepath.Join(destDir, "b", "b.log")))
+	assert.Equal(t, "ttt", vioutil.ReadFile(filepath.Join(destDir, "tmp", "t.txt")))
+	assert.False(t, vioutil.ExistFile(filepath.Join(destDir, "a.txt")))
+
+	assert.NoError(t, vzip.Verify(zipPath))
+}
+
+func TestVerifyReader(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	zw := zip.NewWriter(&buf)
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "a.txt", Method: zip.Store})
+	assert.NoError(t, err)
+
+	_, err = w.Write([]byte("hello world"))
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+
+	data := buf.Bytes()
+	r := bytes.NewReader(data)
+
+	entries, err := vzip.ListReader(r, r.Size())
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	rc, err := vzip.OpenEntryReader(r, r.Size(), "a.txt")
+	assert.NoError(t, err)
+
+	content, err := io.ReadAll(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", string(content))
+
+	assert.NoError(t, vzip.VerifyReader(r, r.Size()))
+
+	// corrupt the stored content.
+	corrupted := bytes.Replace(data, []byte("hello"), []byte("jello"), 1)
+	err = vzip.VerifyReader(bytes.NewReader(corrupted), int64(len(corrupted)))
+	assert.ErrorIs(t, err, zip.ErrChecksum)
+
+	destDir := t.TempDir()
+	assert.NoError(t, vzip.ExtractGlobReader(r, r.Size(), destDir, []string{"*.txt"}, nil))
+	assert.Equal(t, "hello world", vioutil.ReadFile(filepath.Join(destDir, "a.txt")))
+}
+
+func TestExtractGlobLimits(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("bomb.txt")
+	assert.NoError(t, err)
+
+	_, err = w.Write(make([]byte, vzip.DefaultZipLimitSize+1))
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+
+	r := bytes.NewReader(buf.Bytes())
+
+	// the default limits are applied without options.
+	err = vzip.ExtractGlobReader(r, r.Size(), t.TempDir(), []string{"*.txt"}, nil)
+	assert.ErrorIs(t, err, varchive.ErrLimitExceeded)
+
+	assert.NoError(t, vzip.ExtractGlobReader(r, r.Size(), t.TempDir(), []string{"*.txt"}, &varchive.Options{}))
+
+	err = vzip.ExtractGlobReader(r, r.Size(), t.TempDir(), []string{"[a-"}, nil)
+	assert.ErrorIs(t, err, path.ErrBadPattern)
+}
@@ -0,0 +1,258 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vzip
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"io"
+	"os"
+	"slices"
+	"sync"
+)
+
+// spoolMemoryLimit the files up to the size are compressed in memory in parallel mode, the larger ones in temp files.
+const spoolMemoryLimit = 4 << 20
+
+// spool hold the compressed data of a file, in memory or in a temp file.
+type spool struct {
+	buf  *bytes.Buffer
+	file *os.File
+}
+
+func newSpool(size int64, tempDir string) (*spool, error) {
+	if size <= spoolMemoryLimit {
+		return &spool{buf: &bytes.Buffer{}}, nil
+	}
+
+	f, err := os.CreateTemp(tempDir, "vzip-*")
+	if err != nil {
+		return nil, err
+	}
+
+	return &spool{file: f}, nil
+}
+
+func (s *spool) Write(p []byte) (int, error) {
+	if s.file != nil {
+		return s.file.Write(p)
+	}
+
+	return s.buf.Write(p)
+}
+
+func (s *spool) readerAt() (io.ReaderAt, int64, error) {
+	if s.file == nil {
+		return bytes.NewReader(s.buf.Bytes()), int64(s.buf.Len()), nil
+	}
+
+	info, err := s.file.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return s.file, info.Size(), nil
+}
+
+func (s *spool) close() {
+	if s.file != nil {
+		_ = s.file.Close()
+		_ = os.Remove(s.file.Name())
+	}
+}
+
+// compressed a file compressed into a single entry archive, whose raw data is copied to the target archive.
+type compressed struct {
+	spool *spool
+	file  *zip.File
+	err   error
+}
+
+// compress compress the file of the item by a zip.Writer, so that the header is the same as the sequential one.
+func compress(it *item, level int, tempDir string) *compressed {
+	sp, err := newSpool(int64(it.header.UncompressedSize64), tempDir)
+	if err != nil {
+		return &compressed{err: err}
+	}
+
+	c := &compressed{spool: sp}
+
+	zw := zip.NewWriter(sp)
+
+	if level > 0 {
+		zw.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(out, level)
+		})
+	}
+
+	// the header is changed by zip.Writer.
+	header := *it.header
+	header.Extra = slices.Clone(header.Extra)
+
+	if _, c.err = writeItem(zw, &item{path: it.path, header: &header}); c.err != nil {
+		return c
+	}
+
+	if c.err = zw.Close(); c.err != nil {
+		return c
+	}
+
+	r, size, err := sp.readerAt()
+	if err != nil {
+		c.err = err
+		return c
+	}
+
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		c.err = err
+		return c
+	}
+
+	c.file = zr.File[0]
+
+	return c
+}
+
+// writeTo write the compressed entry by zip.Writer.CreateRaw, returns the uncompressed size.
+func (c *compressed) writeTo(zw *zip.Writer) (int64, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	raw, err := c.file.OpenRaw()
+	if err != nil {
+		return 0, err
+	}
+
+	header := c.file.FileHeader
+
+	w, err := zw.CreateRaw(&header)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err = io.Copy(w, raw); err != nil {
+		return 0, err
+	}
+
+	return int64(header.UncompressedSize64), nil
+}
+
+func (c *compressed) close() {
+	if c.spool != nil {
+		c.spool.close()
+	}
+}
+
+// writeParallel compress the files in opts.Parallel goroutines, and write the entries in order.
+// At most twice of opts.Parallel files are compressed ahead of writing.
+func writeParallel(zw *zip.Writer, items []*item, opts *WriteOptions, report func(it *item, n int64)) error {
+	results := make([]chan *compressed, len(items))
+
+	for i, it := range items {
+		if it.isFile() {
+			results[i] = make(chan *compressed, 1)
+		}
+	}
+
+	var (
+		jobs  = make(chan int)
+		done  = make(chan struct{})
+		ahead = make(chan struct{}, opts.Parallel*2)
+		wg    sync.WaitGroup
+	)
+
+	for range opts.Parallel {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				results[i] <- compress(items[i], opts.Level, opts.TempDir)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+
+		for i := range items {
+			if results[i] == nil {
+				continue
+			}
+
+			select {
+			case ahead <- struct{}{}:
+			case <-done:
+				return
+			}
+
+			select {
+			case jobs <- i:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	defer func() {
+		close(done)
+		wg.Wait()
+
+		// release the files compressed but not written on error.
+		for _, result := range results {
+			if result == nil {
+				continue
+			}
+
+			select {
+			case c := <-result:
+				c.close()
+			default:
+			}
+		}
+	}()
+
+	for i, it := range items {
+		var (
+			n   int64
+			err error
+		)
+
+		if results[i] == nil {
+			n, err = writeItem(zw, it)
+		} else {
+			c := <-results[i]
+			<-ahead
+
+			n, err = c.writeTo(zw)
+			c.close()
+		}
+
+		if err != nil {
+			return err
+		}
+
+		report(it, n)
+	}
+
+	return nil
+}
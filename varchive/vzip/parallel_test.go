@@ -0,0 +1,160 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vzip_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/varchive/vzip"
+)
+
+func writeParallelTestDir(t *testing.T) string {
+	t.Helper()
+
+	root := writeTestDir(t)
+
+	// larger than the memory limit, compressed in a temp file.
+	var large []byte
+	for i := 0; len(large) < 5<<20; i++ {
+		large = fmt.Appendf(large, "%08d INFO request served\n", i)
+	}
+
+	large = large[:5<<20]
+
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "large.log"), large, 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "b", "lib.jar"), []byte("jar"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "b", "日志.txt"), []byte("utf8"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "empty.txt"), nil, 0o600))
+
+	return root
+}
+
+func TestWriteParallel(t *testing.T) {
+	t.Parallel()
+
+	root := writeParallelTestDir(t)
+
+	for _, level := range []int{vzip.LevelDefault, vzip.LevelStore, 9} {
+		var sequential, parallel bytes.Buffer
+
+		assert.NoError(t, vzip.Write(&sequential, root, &vzip.WriteOptions{Deterministic: true, Level: level}))
+
+		var progress []vzip.Progress
+
+		assert.NoError(t, vzip.Write(&parallel, root, &vzip.WriteOptions{
+			Deterministic: true,
+			Level:         level,
+			Parallel:      4,
+			OnProgress:    func(p vzip.Progress) { progress = append(progress, p) },
+		}))
+
+		assert.Equal(t, sequential.Bytes(), parallel.Bytes())
+		assert.Len(t, progress, len(entryNames(t, parallel.Bytes())))
+		assert.Equal(t, int64(1000+3+3+3+5+5<<20+3+4), progress[len(progress)-1].Bytes)
+	}
+}
+
+func TestWriteParallelError(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	for i := range 20 {
+		assert.NoError(t, os.WriteFile(filepath.Join(root, string(rune('a'+i))+".txt"), []byte("data"), 0o600))
+	}
+
+	err := vzip.Write(failWriter{}, root, &vzip.WriteOptions{Parallel: 3})
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
+
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) {
+	return 0, os.ErrClosed
+}
+
+func TestAddDirToZipParallel(t *testing.T) {
+	t.Parallel()
+
+	root := writeParallelTestDir(t)
+	assert.NoError(t, os.Remove(filepath.Join(root, "link")))
+
+	write := func(add func(zw *zip.Writer) error) []byte {
+		var buf bytes.Buffer
+
+		zw := zip.NewWriter(&buf)
+		assert.NoError(t, add(zw))
+		assert.NoError(t, zw.Close())
+
+		return buf.Bytes()
+	}
+
+	sequential := write(func(zw *zip.Writer) error {
+		return vzip.AddDirToZip(zw, len(root)+1, root)
+	})
+
+	parallel := write(func(zw *zip.Writer) error {
+		return vzip.AddDirToZipParallel(zw, len(root)+1, root, &vzip.ParallelOptions{Parallel: 4})
+	})
+
+	assert.Equal(t, sequential, parallel)
+	assert.Contains(t, entryNames(t, parallel), "b/c/c.txt")
+
+	// the level of the compressor registered for the sequential writer.
+	sequential = write(func(zw *zip.Writer) error {
+		zw.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(out, flate.BestSpeed)
+		})
+
+		return vzip.AddDirToZip(zw, len(root)+1, root)
+	})
+
+	tempDir := t.TempDir()
+
+	parallel = write(func(zw *zip.Writer) error {
+		return vzip.AddDirToZipParallel(zw, len(root)+1, root, &vzip.ParallelOptions{
+			Parallel: 1,
+			Level:    flate.BestSpeed,
+			TempDir:  tempDir,
+		})
+	})
+
+	assert.Equal(t, sequential, parallel)
+
+	// the temp files are removed.
+	files, err := os.ReadDir(tempDir)
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+
+	// the large file is compressed in a temp file of the directory.
+	err = vzip.AddDirToZipParallel(zip.NewWriter(io.Discard), len(root)+1, root, &vzip.ParallelOptions{
+		TempDir: filepath.Join(tempDir, "none"),
+	})
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	err = vzip.AddDirToZipParallel(zip.NewWriter(io.Discard), len(root)+1, root, &vzip.ParallelOptions{Level: 10})
+	assert.ErrorIs(t, err, vzip.ErrInvalidLevel)
+}
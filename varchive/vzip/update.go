@@ -0,0 +1,301 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vzip
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/vogo/vogo/varchive"
+)
+
+type put struct {
+	data []byte
+	path string
+}
+
+// Update the changes to an existing zip archive, apply it by UpdateFile or UpdateTo.
+//
+//	u := vzip.NewUpdate()
+//	u.PutFile("WEB-INF/web.xml", "web.xml")
+//	u.Delete("META-INF/INDEX.LIST")
+//	err := vzip.UpdateFile("app.war", u)
+type Update struct {
+	puts  map[string]put
+	order []string
+	// deletes the names to delete, mapped to whether the entry must exist in the archive.
+	deletes  map[string]bool
+	comment  *string
+	modified time.Time
+}
+
+// NewUpdate create an empty update.
+func NewUpdate() *Update {
+	return &Update{
+		puts:    make(map[string]put),
+		deletes: make(map[string]bool),
+	}
+}
+
+func (u *Update) put(name string, p put) {
+	if _, ok := u.puts[name]; !ok {
+		u.order = append(u.order, name)
+	}
+
+	u.puts[name] = p
+	delete(u.deletes, name)
+}
+
+// Put add the entry of the name with the data, or replace the existing one.
+// The update fails with varchive.ErrUnsafePath if the name is not a local path, e.g. "/a.txt" or "../a.txt".
+func (u *Update) Put(name string, data []byte) {
+	u.put(name, put{data: data})
+}
+
+// PutFile add the entry of the name with the content of the file, or replace the existing one.
+// The file is read when the update is applied, and the name is checked like Put.
+func (u *Update) PutFile(name, filePath string) {
+	u.put(name, put{path: filePath})
+}
+
+// Delete delete the entry of the name, the update fails with ErrEntryNotFound if it doesn't exist,
+// unless it's added by Put or PutFile before.
+func (u *Update) Delete(name string) {
+	_, ok := u.puts[name]
+	if ok {
+		delete(u.puts, name)
+
+		for i, n := range u.order {
+			if n == name {
+				u.order = append(u.order[:i], u.order[i+1:]...)
+				break
+			}
+		}
+	}
+
+	if _, deleted := u.deletes[name]; !deleted || ok {
+		u.deletes[name] = !ok
+	}
+}
+
+// SetComment set the comment of the archive.
+func (u *Update) SetComment(comment string) {
+	u.comment = &comment
+}
+
+// SetModified set the modification time of the entries added by Put and PutFile.
+// By default, it's DeterministicTime if all entries of the archive have it, e.g. written by Write in deterministic mode,
+// otherwise the current time for Put and the modification time of the file for PutFile.
+func (u *Update) SetModified(modified time.Time) {
+	u.modified = modified
+}
+
+// UpdateFile apply the update to the zip archive file, which is replaced atomically after rewriting.
+// The unchanged entries are copied without decompressing and compressing again.
+func UpdateFile(src string, u *Update) (err error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(src), "."+filepath.Base(src)+".*")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	if err = updateFileTo(tmp, src, u); err != nil {
+		return err
+	}
+
+	if err = tmp.Chmod(info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	// the source is closed before replaced.
+	return os.Rename(tmp.Name(), src)
+}
+
+func updateFileTo(w io.Writer, src string, u *Update) error {
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	return update(w, &r.Reader, u)
+}
+
+// UpdateTo apply the update to the zip archive of the size read from r, and write the result to w.
+func UpdateTo(w io.Writer, r io.ReaderAt, size int64, u *Update) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+
+	return update(w, zr, u)
+}
+
+func update(w io.Writer, zr *zip.Reader, u *Update) error {
+	for _, name := range u.order {
+		if !filepath.IsLocal(filepath.FromSlash(name)) {
+			return fmt.Errorf("%w: %s", varchive.ErrUnsafePath, name)
+		}
+	}
+
+	existing := make(map[string]bool, len(zr.File))
+	for _, f := range zr.File {
+		existing[f.Name] = true
+	}
+
+	for name, required := range u.deletes {
+		if required && !existing[name] {
+			return fmt.Errorf("%w: %s", ErrEntryNotFound, name)
+		}
+	}
+
+	zw := zip.NewWriter(w)
+
+	if u.comment != nil {
+		if err := zw.SetComment(*u.comment); err != nil {
+			return err
+		}
+	} else if err := zw.SetComment(zr.Comment); err != nil {
+		return err
+	}
+
+	modified := u.modified
+	if modified.IsZero() && isDeterministic(zr.File) {
+		modified = DeterministicTime
+	}
+
+	written := make(map[string]bool, len(u.puts))
+
+	for _, f := range zr.File {
+		if _, ok := u.deletes[f.Name]; ok || written[f.Name] {
+			continue
+		}
+
+		// replaced in the original position.
+		if p, ok := u.puts[f.Name]; ok {
+			if err := writePut(zw, f.Name, p, modified); err != nil {
+				return err
+			}
+
+			written[f.Name] = true
+
+			continue
+		}
+
+		if err := zw.Copy(f); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range u.order {
+		if written[name] {
+			continue
+		}
+
+		if err := writePut(zw, name, u.puts[name], modified); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// isDeterministic returns whether all the entries have DeterministicTime.
+func isDeterministic(files []*zip.File) bool {
+	for _, f := range files {
+		if !f.Modified.Equal(DeterministicTime) {
+			return false
+		}
+	}
+
+	return len(files) > 0
+}
+
+// writePut write the entry, the modification time is the current time for data
+// or the time of the file if modified is zero.
+func writePut(zw *zip.Writer, name string, p put, modified time.Time) error {
+	var (
+		header *zip.FileHeader
+		r      io.Reader
+	)
+
+	if p.path == "" {
+		header = &zip.FileHeader{
+			Name:     name,
+			Method:   chooseCompressMethod(filepath.Ext(name)),
+			Modified: time.Now(),
+		}
+		header.SetMode(0o644)
+
+		r = bytes.NewReader(p.data)
+	} else {
+		f, err := os.Open(p.path)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+
+		if header, err = fileHeader(info, p.path, name); err != nil {
+			return err
+		}
+
+		r = f
+	}
+
+	if !modified.IsZero() {
+		header.Modified = modified
+	}
+
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, r)
+
+	return err
+}
@@ -0,0 +1,218 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vzip_test
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/varchive"
+	"github.com/vogo/vogo/varchive/vzip"
+)
+
+func readEntry(t *testing.T, zipPath, name string) string {
+	t.Helper()
+
+	rc, err := vzip.OpenEntry(zipPath, name)
+	assert.NoError(t, err)
+
+	data, err := io.ReadAll(rc)
+	assert.NoError(t, err)
+	assert.NoError(t, rc.Close())
+
+	return string(data)
+}
+
+func TestUpdateFile(t *testing.T) {
+	t.Parallel()
+
+	root := writeTestDir(t)
+	zipPath := filepath.Join(t.TempDir(), "test.zip")
+
+	f, err := os.Create(zipPath)
+	assert.NoError(t, err)
+	assert.NoError(t, vzip.Write(f, root, nil))
+	assert.NoError(t, f.Close())
+
+	before, err := vzip.List(zipPath)
+	assert.NoError(t, err)
+
+	newFile := filepath.Join(t.TempDir(), "new.txt")
+	assert.NoError(t, os.WriteFile(newFile, []byte("new"), 0o600))
+
+	u := vzip.NewUpdate()
+	u.Put("b/c/c.txt", []byte("replaced"))
+	u.PutFile("new.txt", newFile)
+	u.Put("x.txt", []byte("x"))
+	u.Delete("x.txt")
+	u.Delete("tmp/t.txt")
+	u.SetComment("patched")
+
+	assert.NoError(t, vzip.UpdateFile(zipPath, u))
+
+	entries, err := vzip.List(zipPath)
+	assert.NoError(t, err)
+	assert.Len(t, entries, len(before))
+	assert.Equal(t, "new.txt", entries[len(entries)-1].Name)
+
+	// unchanged entries keep the order and compressed data.
+	assert.Equal(t, before[0], entries[0])
+
+	assert.Equal(t, "replaced", readEntry(t, zipPath, "b/c/c.txt"))
+	assert.Equal(t, "new", readEntry(t, zipPath, "new.txt"))
+
+	_, err = vzip.OpenEntry(zipPath, "tmp/t.txt")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	_, err = vzip.OpenEntry(zipPath, "x.txt")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	assert.NoError(t, vzip.Verify(zipPath))
+
+	// no temp file left.
+	files, err := os.ReadDir(filepath.Dir(zipPath))
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestUpdateTo(t *testing.T) {
+	t.Parallel()
+
+	var src bytes.Buffer
+
+	u := vzip.NewUpdate()
+	u.Put("a.txt", []byte("aaa"))
+	u.SetComment("first")
+	assert.NoError(t, vzip.UpdateTo(&src, bytes.NewReader(emptyZip(t)), int64(len(emptyZip(t))), u))
+
+	u = vzip.NewUpdate()
+	u.Delete("none")
+
+	var dst bytes.Buffer
+
+	err := vzip.UpdateTo(&dst, bytes.NewReader(src.Bytes()), int64(src.Len()), u)
+	assert.ErrorIs(t, err, vzip.ErrEntryNotFound)
+
+	// the comment is kept if not set.
+	u = vzip.NewUpdate()
+	u.Put("b.txt", []byte("bbb"))
+
+	dst.Reset()
+	assert.NoError(t, vzip.UpdateTo(&dst, bytes.NewReader(src.Bytes()), int64(src.Len()), u))
+
+	entries, err := vzip.ListReader(bytes.NewReader(dst.Bytes()), int64(dst.Len()))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "a.txt", entries[0].Name)
+	assert.Equal(t, "b.txt", entries[1].Name)
+	assert.Contains(t, dst.String(), "first")
+}
+
+func TestUpdateUnsafeName(t *testing.T) {
+	t.Parallel()
+
+	src := emptyZip(t)
+
+	for _, name := range []string{"/etc/passwd", "../a.txt", "a/../../b.txt", ""} {
+		u := vzip.NewUpdate()
+		u.Put("a.txt", []byte("aaa"))
+		u.Put(name, []byte("evil"))
+
+		var dst bytes.Buffer
+
+		err := vzip.UpdateTo(&dst, bytes.NewReader(src), int64(len(src)), u)
+		assert.ErrorIs(t, err, varchive.ErrUnsafePath, name)
+		assert.Zero(t, dst.Len(), name)
+	}
+
+	u := vzip.NewUpdate()
+	u.PutFile("../a.txt", filepath.Join(t.TempDir(), "a.txt"))
+
+	err := vzip.UpdateTo(io.Discard, bytes.NewReader(src), int64(len(src)), u)
+	assert.ErrorIs(t, err, varchive.ErrUnsafePath)
+}
+
+func emptyZip(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	assert.NoError(t, vzip.Write(&buf, t.TempDir(), nil))
+
+	return buf.Bytes()
+}
+
+func TestUpdateReproducible(t *testing.T) {
+	t.Parallel()
+
+	root := writeTestDir(t)
+
+	var src bytes.Buffer
+	assert.NoError(t, vzip.Write(&src, root, &vzip.WriteOptions{Deterministic: true}))
+
+	newFile := filepath.Join(t.TempDir(), "new.txt")
+	assert.NoError(t, os.WriteFile(newFile, []byte("new"), 0o600))
+
+	apply := func(src []byte, modify func(u *vzip.Update)) []byte {
+		u := vzip.NewUpdate()
+		u.Put("a.txt", []byte("replaced"))
+		u.PutFile("new.txt", newFile)
+
+		if modify != nil {
+			modify(u)
+		}
+
+		var dst bytes.Buffer
+		assert.NoError(t, vzip.UpdateTo(&dst, bytes.NewReader(src), int64(len(src)), u))
+
+		return dst.Bytes()
+	}
+
+	// DeterministicTime is kept for deterministic archives.
+	first := apply(src.Bytes(), nil)
+	time.Sleep(1100 * time.Millisecond)
+	assert.Equal(t, first, apply(src.Bytes(), nil))
+
+	entries, err := vzip.ListReader(bytes.NewReader(first), int64(len(first)))
+	assert.NoError(t, err)
+
+	for _, e := range entries {
+		assert.True(t, e.Modified.Equal(vzip.DeterministicTime), e.Name)
+	}
+
+	// the time set is used for the archives of the other times.
+	modified := time.Date(2024, 1, 2, 3, 4, 6, 0, time.UTC)
+	setModified := func(u *vzip.Update) { u.SetModified(modified) }
+
+	var other bytes.Buffer
+	assert.NoError(t, vzip.Write(&other, root, nil))
+
+	second := apply(other.Bytes(), setModified)
+	assert.Equal(t, second, apply(other.Bytes(), setModified))
+
+	entries, err = vzip.ListReader(bytes.NewReader(second), int64(len(second)))
+	assert.NoError(t, err)
+	assert.True(t, entries[0].Modified.Equal(modified))
+	assert.True(t, entries[len(entries)-1].Modified.Equal(modified))
+}
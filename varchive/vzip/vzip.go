@@ -21,15 +21,24 @@ package vzip
 
 import (
 	"archive/zip"
-	"errors"
-	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	"strings"
+	"runtime"
+
+	"github.com/vogo/vogo/varchive"
 )
 
-const DefaultZipLimitSize = 8 * 1024 * 1024
+const (
+	DefaultZipLimitSize = 8 * 1024 * 1024
+
+	// DefaultZipLimitTotalSize the default max size of all files extracted by Unzip and LimitUnzip.
+	DefaultZipLimitTotalSize = 1024 * 1024 * 1024
+	// DefaultZipMaxEntries the default max count of the entries extracted by Unzip and LimitUnzip.
+	DefaultZipMaxEntries = 100000
+	// DefaultZipMaxCompressionRatio the default max compression ratio of an entry extracted by Unzip and LimitUnzip.
+	DefaultZipMaxCompressionRatio = 200
+)
 
 // Unzip will decompress a zip archive, moving all files and folders
 // within the zip file (parameter 1) to an output directory (parameter 2).
@@ -38,86 +47,60 @@ func Unzip(src, destDir string) error {
 }
 
 // LimitUnzip decompress a zip archive, while limit the size of a single containing file.
+// The total size, the entry count and the compression ratio are limited by the defaults too.
+// An error wrapping varchive.ErrLimitExceeded is returned if any limit is exceeded,
+// and an error wrapping varchive.ErrUnsafePath is returned if an entry escapes the destination directory.
 func LimitUnzip(src, destDir string, limitSize int64) error {
-	r, err := zip.OpenReader(src)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		_ = r.Close()
-	}()
-
-	// Make File
-	if err := os.MkdirAll(destDir, os.ModePerm); err != nil {
-		return err
-	}
-
-	for _, zipFile := range r.File {
-		if err := unzipFile(destDir, zipFile, limitSize); err != nil {
-			return err
-		}
-	}
+	opts := DefaultOptions()
+	opts.MaxFileSize = limitSize
 
-	return nil
+	return UnzipWithOptions(src, destDir, opts)
 }
 
-func unzipFile(destDir string, f *zip.File, limitSize int64) error {
-	// Check for ZipSlip
-	fileName := strings.ReplaceAll(f.Name, "..", "")
-
-	if fileName != f.Name {
-		return fmt.Errorf("%w: invalid zip file %s", os.ErrInvalid, f.Name)
-	}
-
-	targetPath := filepath.Join(destDir, fileName)
-
-	if f.FileInfo().IsDir() {
-		return os.MkdirAll(targetPath, f.Mode())
-	}
-
-	if err := os.MkdirAll(filepath.Dir(targetPath), os.ModePerm); err != nil {
-		return err
-	}
-
-	outFile, err := os.OpenFile(targetPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
-	if err != nil {
-		return err
+// DefaultOptions returns the extraction options of Unzip, which limit the file size, the total size,
+// the entry count and the compression ratio to the defaults.
+func DefaultOptions() *varchive.Options {
+	return &varchive.Options{
+		MaxFileSize:         DefaultZipLimitSize,
+		MaxTotalSize:        DefaultZipLimitTotalSize,
+		MaxEntries:          DefaultZipMaxEntries,
+		MaxCompressionRatio: DefaultZipMaxCompressionRatio,
+		PreserveMode:        true,
 	}
+}
 
-	rc, err := f.Open()
+// UnzipWithOptions decompress a zip archive with the options.
+func UnzipWithOptions(src, destDir string, opts *varchive.Options) error {
+	r, err := zip.OpenReader(src)
 	if err != nil {
 		return err
 	}
+	defer func() {
+		_ = r.Close()
+	}()
 
-	_, err = io.CopyN(outFile, rc, limitSize)
-
-	if closeErr := outFile.Close(); closeErr != nil {
-		_ = rc.Close()
-		return closeErr
-	}
-	_ = rc.Close()
-
-	// ignore EOF for copyN
-	if errors.Is(err, io.EOF) {
-		return nil
-	}
-
-	return err
+	return varchive.ExtractZipFiles(r.File, destDir, opts)
 }
 
 // ZipDir compresses a directory into a zip archive file.
-func ZipDir(zipPath, dir string) error {
+// The directory itself is the top entry of the archive if the path doesn't end with "/",
+// use Write for the filters, the directory entries and the symlinks.
+func ZipDir(zipPath, dir string) (err error) {
 	newZipFile, err := os.Create(zipPath)
 	if err != nil {
 		return err
 	}
 	defer func() {
-		_ = newZipFile.Close()
+		if closeErr := newZipFile.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
 	}()
 
 	zipWriter := zip.NewWriter(newZipFile)
 	defer func() {
-		_ = zipWriter.Close()
+		if closeErr := zipWriter.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
 	}()
 
 	baseDirLen := len(dir)
@@ -139,6 +122,74 @@ func AddDirToZip(writer *zip.Writer, baseDirLen int, dir string) error {
 	})
 }
 
+// ParallelOptions the options of AddDirToZipParallel.
+type ParallelOptions struct {
+	// Parallel the count of the goroutines compressing files, default runtime.NumCPU().
+	Parallel int
+	// Level the deflate level like WriteOptions.Level.
+	Level int
+	// TempDir the directory of the temp files holding the compressed large files, default os.TempDir().
+	TempDir string
+}
+
+// AddDirToZipParallel add all files under the target directory into a zip file like AddDirToZip,
+// but compress the files in parallel goroutines.
+// The files are compressed by their own writers, so the compressor registered on the writer is not used,
+// set opts.Level to get the same output as AddDirToZip with a compressor of the flate level.
+func AddDirToZipParallel(writer *zip.Writer, baseDirLen int, dir string, opts *ParallelOptions) error {
+	if opts == nil {
+		opts = &ParallelOptions{}
+	}
+
+	writeOpts := &WriteOptions{
+		Level:    opts.Level,
+		Parallel: opts.Parallel,
+		TempDir:  opts.TempDir,
+	}
+
+	if writeOpts.Parallel <= 0 {
+		writeOpts.Parallel = runtime.NumCPU()
+	}
+
+	if err := checkOptions(writeOpts); err != nil {
+		return err
+	}
+
+	var items []*item
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if dir == path || info.IsDir() {
+			return nil
+		}
+
+		// follow symlinks like AddFileToZip.
+		if info, err = os.Stat(path); err != nil {
+			return err
+		}
+
+		header, err := fileHeader(info, path, path[baseDirLen:])
+		if err != nil {
+			return err
+		}
+
+		items = append(items, &item{path: path, header: header})
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if writeOpts.Level == LevelStore {
+		for _, it := range items {
+			it.header.Method = zip.Store
+		}
+	}
+
+	// compressed by the parallel writers even if only one goroutine, so that the output is consistent.
+	return writeParallel(writer, items, writeOpts, func(*item, int64) {})
+}
+
 // AddFileToZip add a single file into a zip file.
 func AddFileToZip(zipWriter *zip.Writer, filePath, pathInZip string) error {
 	fileToZip, err := os.Open(filePath)
@@ -155,17 +206,11 @@ func AddFileToZip(zipWriter *zip.Writer, filePath, pathInZip string) error {
 		return err
 	}
 
-	header, err := zip.FileInfoHeader(info)
+	header, err := fileHeader(info, filePath, pathInZip)
 	if err != nil {
 		return err
 	}
 
-	// Using FileInfoHeader() above only uses the basename of the file. If we want
-	// to preserve the folder structure we can overwrite this with the full path.
-	header.Name = pathInZip
-
-	header.Method = chooseCompressMethod(filepath.Ext(filePath))
-
 	writer, err := zipWriter.CreateHeader(header)
 	if err != nil {
 		return err
@@ -176,6 +221,21 @@ func AddFileToZip(zipWriter *zip.Writer, filePath, pathInZip string) error {
 	return err
 }
 
+func fileHeader(info os.FileInfo, filePath, pathInZip string) (*zip.FileHeader, error) {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return nil, err
+	}
+
+	// Using FileInfoHeader() above only uses the basename of the file. If we want
+	// to preserve the folder structure we can overwrite this with the full path.
+	header.Name = pathInZip
+
+	header.Method = chooseCompressMethod(filepath.Ext(filePath))
+
+	return header, nil
+}
+
 func chooseCompressMethod(ext string) uint16 {
 	switch ext {
 	case ".jar", ".z", ".gz", ".tar", ".zip":
@@ -20,11 +20,14 @@
 package vzip_test
 
 import (
+	"archive/zip"
+	"bytes"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/varchive"
 	"github.com/vogo/vogo/varchive/vzip"
 	"github.com/vogo/vogo/vio/vioutil"
 )
@@ -52,6 +55,12 @@ func TestZipDir(t *testing.T) {
 	t.Logf("zip dir: %s", zipDir)
 
 	assert.NoError(t, vzip.ZipDir(zipPath, zipDir))
+
+	// only the files are added, with the names relative to the parent of the directory.
+	data, err := os.ReadFile(zipPath)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/a/a1.txt", "/a/a2.txt", "/a/b/b1.txt", "/a/b/b2.txt"}, entryNames(t, data))
+
 	assert.NoError(t, vzip.Unzip(zipPath, outputDir))
 	assert.True(t, vioutil.ExistFile(filepath.Join(outputDir, "a", "a1.txt")))
 	assert.True(t, vioutil.ExistFile(filepath.Join(outputDir, "a", "a2.txt")))
@@ -75,3 +84,106 @@ func TestZipDir(t *testing.T) {
 	assert.True(t, vioutil.ExistFile(filepath.Join(outputDir, "b", "b2.txt")))
 	assert.Equal(t, "bbb1", vioutil.ReadFile(filepath.Join(outputDir, "b", "b1.txt")))
 }
+
+type testEntry struct {
+	header  *zip.FileHeader
+	content []byte
+}
+
+func writeTestZip(t *testing.T, entries ...testEntry) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	zw := zip.NewWriter(&buf)
+
+	for _, e := range entries {
+		w, err := zw.CreateHeader(e.header)
+		assert.NoError(t, err)
+
+		_, err = w.Write(e.content)
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, zw.Close())
+
+	zipPath := filepath.Join(t.TempDir(), "test.zip")
+	assert.NoError(t, os.WriteFile(zipPath, buf.Bytes(), 0o600))
+
+	return zipPath
+}
+
+func TestUnzipUnsafe(t *testing.T) {
+	t.Parallel()
+
+	symlink := func(name, target string) testEntry {
+		header := &zip.FileHeader{Name: name, Method: zip.Store}
+		header.SetMode(os.ModeSymlink | 0o777)
+
+		return testEntry{header: header, content: []byte(target)}
+	}
+
+	for _, entries := range [][]testEntry{
+		{{header: &zip.FileHeader{Name: "../evil.txt"}}},
+		{{header: &zip.FileHeader{Name: "/../evil.txt"}}},
+		{{header: &zip.FileHeader{Name: `a\..\..\evil.txt`}}},
+		{symlink("link", "../../etc")},
+		// climb out through a symlink to the dest dir.
+		{symlink("d", "."), symlink("e", "d/..")},
+		{symlink("d", "."), symlink("d/e", "..")},
+	} {
+		err := vzip.Unzip(writeTestZip(t, entries...), t.TempDir())
+		assert.ErrorIs(t, err, varchive.ErrUnsafePath, entries[len(entries)-1].header.Name)
+	}
+
+	destDir := t.TempDir()
+	assert.NoError(t, vzip.Unzip(writeTestZip(t, testEntry{header: &zip.FileHeader{Name: "a..b.txt"}, content: []byte("ab")}), destDir))
+	assert.Equal(t, "ab", vioutil.ReadFile(filepath.Join(destDir, "a..b.txt")))
+}
+
+func TestUnzipLimit(t *testing.T) {
+	t.Parallel()
+
+	content := bytes.Repeat([]byte("a"), 1024)
+
+	zipPath := writeTestZip(t,
+		testEntry{header: &zip.FileHeader{Name: "a.txt", Method: zip.Store}, content: content},
+		testEntry{header: &zip.FileHeader{Name: "b.txt", Method: zip.Store}, content: content},
+	)
+
+	// error instead of truncating.
+	assert.ErrorIs(t, vzip.LimitUnzip(zipPath, t.TempDir(), 100), varchive.ErrLimitExceeded)
+
+	assert.ErrorIs(t, vzip.UnzipWithOptions(zipPath, t.TempDir(), &varchive.Options{MaxTotalSize: 1500}),
+		varchive.ErrLimitExceeded)
+	assert.ErrorIs(t, vzip.UnzipWithOptions(zipPath, t.TempDir(), &varchive.Options{MaxEntries: 1}),
+		varchive.ErrLimitExceeded)
+	assert.NoError(t, vzip.UnzipWithOptions(zipPath, t.TempDir(), &varchive.Options{MaxTotalSize: 2048, MaxEntries: 2}))
+
+	bomb := writeTestZip(t, testEntry{
+		header:  &zip.FileHeader{Name: "bomb.txt", Method: zip.Deflate},
+		content: make([]byte, 4*1024*1024),
+	})
+	assert.ErrorIs(t, vzip.LimitUnzip(bomb, t.TempDir(), 8*1024*1024), varchive.ErrLimitExceeded)
+}
+
+// TestUnzipLeadingSlash unzip the archives written by the early versions of AddDirToZip,
+// whose entry names start with "/".
+func TestUnzipLeadingSlash(t *testing.T) {
+	t.Parallel()
+
+	zipPath := writeTestZip(t,
+		testEntry{header: &zip.FileHeader{Name: "/a.txt", Method: zip.Deflate}, content: []byte("aaa")},
+		testEntry{header: &zip.FileHeader{Name: "/b/c.txt", Method: zip.Deflate}, content: []byte("ccc")},
+	)
+
+	destDir := t.TempDir()
+	assert.NoError(t, vzip.Unzip(zipPath, destDir))
+	assert.Equal(t, "aaa", vioutil.ReadFile(filepath.Join(destDir, "a.txt")))
+	assert.Equal(t, "ccc", vioutil.ReadFile(filepath.Join(destDir, "b", "c.txt")))
+
+	globDir := t.TempDir()
+	assert.NoError(t, vzip.ExtractGlob(zipPath, globDir, []string{"b/*"}, nil))
+	assert.Equal(t, "ccc", vioutil.ReadFile(filepath.Join(globDir, "b", "c.txt")))
+	assert.False(t, vioutil.ExistFile(filepath.Join(globDir, "a.txt")))
+}
@@ -0,0 +1,392 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vzip
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DeterministicTime the modification time of all entries in deterministic archives, the minimum time of zip.
+var DeterministicTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const (
+	// LevelDefault the default deflate level, flate.DefaultCompression is accepted as well.
+	LevelDefault = 0
+	// LevelStore store files without compression, which is distinct from the flate levels.
+	LevelStore = -100
+)
+
+var ErrInvalidLevel = errors.New("invalid compression level")
+
+// Progress the progress of writing an archive.
+type Progress struct {
+	// Name the name of the entry just written.
+	Name string
+	// Entries the count of the entries written.
+	Entries int
+	// Bytes the uncompressed size of the files written.
+	Bytes int64
+}
+
+// WriteOptions the options of writing an archive.
+type WriteOptions struct {
+	// Prefix the directory in the archive containing the files, e.g. "app".
+	Prefix string
+	// Include the glob patterns of the files to include, all files are included if empty.
+	// A pattern without "/" matches the base name, otherwise the slash separated path relative to the root.
+	Include []string
+	// Exclude the glob patterns of the files and directories to exclude, matched like Include.
+	Exclude []string
+	// Level the deflate level from flate.BestSpeed to flate.BestCompression, LevelDefault or LevelStore.
+	// Note that the zero value is the default level rather than flate.NoCompression, use LevelStore to store files.
+	// Files of compressed formats, e.g. ".jar" and ".gz", are always stored.
+	Level int
+	// Deterministic build reproducible archives, the entries are sorted and their times are DeterministicTime.
+	Deterministic bool
+	// OnProgress is called after each entry is written.
+	OnProgress func(p Progress)
+	// Parallel the count of the goroutines compressing files concurrently, files are compressed one by one if not greater than 1.
+	// The entries are still written in order, and the archive is the same as the sequential one.
+	Parallel int
+	// TempDir the directory of the temp files holding the compressed large files in parallel mode, default os.TempDir().
+	TempDir string
+}
+
+// Write write the files, directories and symlinks under root to w as a zip archive,
+// the entries are sorted by name and the empty directories are kept.
+func Write(w io.Writer, root string, opts *WriteOptions) error {
+	if opts == nil {
+		opts = &WriteOptions{}
+	}
+
+	if err := checkOptions(opts); err != nil {
+		return err
+	}
+
+	items, err := collect(root, opts)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+
+	if opts.Level > 0 {
+		zw.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(out, opts.Level)
+		})
+	}
+
+	if err = writeItems(zw, items, opts); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// writeItems write the items in order, compressing the files in parallel if opts.Parallel is greater than 1.
+func writeItems(zw *zip.Writer, items []*item, opts *WriteOptions) error {
+	progress := Progress{}
+
+	report := func(it *item, n int64) {
+		progress.Name = it.header.Name
+		progress.Entries++
+		progress.Bytes += n
+
+		if opts.OnProgress != nil {
+			opts.OnProgress(progress)
+		}
+	}
+
+	if opts.Parallel > 1 {
+		return writeParallel(zw, items, opts, report)
+	}
+
+	for _, it := range items {
+		n, err := writeItem(zw, it)
+		if err != nil {
+			return err
+		}
+
+		report(it, n)
+	}
+
+	return nil
+}
+
+// item a file to write into an archive.
+type item struct {
+	path   string
+	header *zip.FileHeader
+	// link the target of a symlink.
+	link string
+}
+
+// isFile returns whether the item is a regular file.
+func (it *item) isFile() bool {
+	return it.path != "" && it.link == ""
+}
+
+// collect walk the root and build the headers of the entries in order.
+func collect(root string, opts *WriteOptions) ([]*item, error) {
+	var (
+		items []*item
+		dirs  = map[string]bool{}
+	)
+
+	prefix := strings.Trim(filepath.ToSlash(opts.Prefix), "/")
+
+	// addDir add the directory entry and its parents, which are sorted before the entries under them.
+	var addDir func(rel string, info fs.FileInfo) error
+
+	addDir = func(rel string, info fs.FileInfo) error {
+		if rel == "." || dirs[rel] {
+			return nil
+		}
+
+		if parent := path.Dir(rel); parent != "." && !dirs[parent] {
+			parentInfo, err := os.Stat(filepath.Join(root, filepath.FromSlash(parent)))
+			if err != nil {
+				return err
+			}
+
+			if err = addDir(parent, parentInfo); err != nil {
+				return err
+			}
+		}
+
+		dirs[rel] = true
+
+		header, err := newHeader(info, path.Join(prefix, rel)+"/", opts)
+		if err != nil {
+			return err
+		}
+
+		items = append(items, &item{header: header})
+
+		return nil
+	}
+
+	if prefix != "" {
+		rootInfo, err := os.Stat(root)
+		if err != nil {
+			return nil, err
+		}
+
+		// the directories of the prefix.
+		for i, c := range prefix {
+			if c == '/' {
+				if err = addPrefixDir(&items, prefix[:i+1], rootInfo, opts); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if err = addPrefixDir(&items, prefix+"/", rootInfo, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			return nil
+		}
+
+		if matchAny(opts.Exclude, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			// directories are added with the included files if filtered.
+			if len(opts.Include) > 0 {
+				return nil
+			}
+
+			return addDir(rel, info)
+		}
+
+		if len(opts.Include) > 0 && !matchAny(opts.Include, rel) {
+			return nil
+		}
+
+		if parent := path.Dir(rel); parent != "." {
+			parentInfo, err := os.Stat(filepath.Dir(p))
+			if err != nil {
+				return err
+			}
+
+			if err = addDir(parent, parentInfo); err != nil {
+				return err
+			}
+		}
+
+		it := &item{path: p}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			if it.link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		} else if !info.Mode().IsRegular() {
+			// devices, sockets and the others are ignored.
+			return nil
+		}
+
+		if it.header, err = newHeader(info, path.Join(prefix, rel), opts); err != nil {
+			return err
+		}
+
+		items = append(items, it)
+
+		return nil
+	})
+
+	return items, err
+}
+
+func addPrefixDir(items *[]*item, name string, info fs.FileInfo, opts *WriteOptions) error {
+	header, err := newHeader(info, name, opts)
+	if err != nil {
+		return err
+	}
+
+	*items = append(*items, &item{header: header})
+
+	return nil
+}
+
+func newHeader(info fs.FileInfo, name string, opts *WriteOptions) (*zip.FileHeader, error) {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return nil, err
+	}
+
+	header.Name = name
+
+	switch {
+	case info.IsDir(), info.Mode()&os.ModeSymlink != 0, opts.Level == LevelStore:
+		header.Method = zip.Store
+	default:
+		header.Method = chooseCompressMethod(filepath.Ext(name))
+	}
+
+	if opts.Deterministic {
+		header.Modified = DeterministicTime
+	}
+
+	return header, nil
+}
+
+// writeItem returns the uncompressed size written.
+func writeItem(zw *zip.Writer, it *item) (int64, error) {
+	w, err := zw.CreateHeader(it.header)
+	if err != nil {
+		return 0, err
+	}
+
+	if it.link != "" {
+		n, err := io.WriteString(w, it.link)
+
+		return int64(n), err
+	}
+
+	if it.path == "" {
+		// directory
+		return 0, nil
+	}
+
+	f, err := os.Open(it.path)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	return io.Copy(w, f)
+}
+
+// checkOptions check the level and the patterns, so that a bad pattern doesn't silently match nothing.
+func checkOptions(opts *WriteOptions) error {
+	switch {
+	case opts.Level == LevelDefault, opts.Level == LevelStore, opts.Level == flate.DefaultCompression:
+	case opts.Level >= flate.BestSpeed && opts.Level <= flate.BestCompression:
+	default:
+		return fmt.Errorf("%w: %d", ErrInvalidLevel, opts.Level)
+	}
+
+	if err := checkPatterns(opts.Include); err != nil {
+		return err
+	}
+
+	return checkPatterns(opts.Exclude)
+}
+
+// checkPatterns returns path.ErrBadPattern if any of the patterns is malformed.
+func checkPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%w: %s", err, pattern)
+		}
+	}
+
+	return nil
+}
+
+// matchAny returns whether the slash separated relative path matches any of the patterns.
+func matchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		name := rel
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(rel)
+		}
+
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,149 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vzip_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/varchive/vzip"
+)
+
+func writeTestDir(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "b", "c"), os.ModePerm))
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "empty"), os.ModePerm))
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "tmp"), os.ModePerm))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "a.txt"), bytes.Repeat([]byte("a"), 1000), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "b", "b.log"), []byte("bbb"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "b", "c", "c.txt"), []byte("ccc"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "tmp", "t.txt"), []byte("ttt"), 0o600))
+	assert.NoError(t, os.Symlink("a.txt", filepath.Join(root, "link")))
+
+	return root
+}
+
+func entryNames(t *testing.T, data []byte) []string {
+	t.Helper()
+
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+
+	names := make([]string, len(r.File))
+	for i, f := range r.File {
+		names[i] = f.Name
+	}
+
+	return names
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	root := writeTestDir(t)
+
+	var (
+		buf      bytes.Buffer
+		progress []vzip.Progress
+	)
+
+	assert.NoError(t, vzip.Write(&buf, root, &vzip.WriteOptions{
+		Prefix:  "app",
+		Exclude: []string{"tmp"},
+		OnProgress: func(p vzip.Progress) {
+			progress = append(progress, p)
+		},
+	}))
+
+	assert.Equal(t, []string{
+		"app/", "app/a.txt", "app/b/", "app/b/b.log", "app/b/c/", "app/b/c/c.txt", "app/empty/", "app/link",
+	}, entryNames(t, buf.Bytes()))
+
+	assert.Len(t, progress, 8)
+	assert.Equal(t, int64(1000+3+3+len("a.txt")), progress[7].Bytes)
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	assert.Equal(t, os.ModeSymlink, r.File[7].Mode()&os.ModeSymlink)
+
+	buf.Reset()
+	assert.NoError(t, vzip.Write(&buf, root, &vzip.WriteOptions{Include: []string{"*.txt"}, Exclude: []string{"tmp"}}))
+	assert.Equal(t, []string{"a.txt", "b/", "b/c/", "b/c/c.txt"}, entryNames(t, buf.Bytes()))
+
+	buf.Reset()
+	assert.NoError(t, vzip.Write(&buf, root, &vzip.WriteOptions{Include: []string{"b/*.log"}, Level: vzip.LevelStore}))
+	assert.Equal(t, []string{"b/", "b/b.log"}, entryNames(t, buf.Bytes()))
+}
+
+func TestWriteDeterministic(t *testing.T) {
+	t.Parallel()
+
+	root := writeTestDir(t)
+
+	var first, second bytes.Buffer
+
+	opts := &vzip.WriteOptions{Deterministic: true, Level: 9}
+
+	assert.NoError(t, vzip.Write(&first, root, opts))
+
+	now := time.Now()
+	assert.NoError(t, os.Chtimes(filepath.Join(root, "a.txt"), now, now))
+
+	assert.NoError(t, vzip.Write(&second, root, opts))
+	assert.Equal(t, first.Bytes(), second.Bytes())
+}
+
+func TestWriteOptionsInvalid(t *testing.T) {
+	t.Parallel()
+
+	root := writeTestDir(t)
+
+	var buf bytes.Buffer
+
+	err := vzip.Write(&buf, root, &vzip.WriteOptions{Include: []string{"[a-"}})
+	assert.ErrorIs(t, err, path.ErrBadPattern)
+
+	err = vzip.Write(&buf, root, &vzip.WriteOptions{Exclude: []string{"*.txt", "[a-"}})
+	assert.ErrorIs(t, err, path.ErrBadPattern)
+
+	for _, level := range []int{10, flate.HuffmanOnly} {
+		err = vzip.Write(&buf, root, &vzip.WriteOptions{Level: level})
+		assert.ErrorIs(t, err, vzip.ErrInvalidLevel)
+	}
+
+	// flate.DefaultCompression is the default level.
+	var defaultLevel, flateDefault bytes.Buffer
+
+	assert.NoError(t, vzip.Write(&defaultLevel, root, &vzip.WriteOptions{Deterministic: true}))
+	assert.NoError(t, vzip.Write(&flateDefault, root, &vzip.WriteOptions{Deterministic: true, Level: flate.DefaultCompression}))
+	assert.Equal(t, defaultLevel.Bytes(), flateDefault.Bytes())
+
+	r, err := zip.NewReader(bytes.NewReader(flateDefault.Bytes()), int64(flateDefault.Len()))
+	assert.NoError(t, err)
+	assert.Equal(t, zip.Deflate, r.File[0].Method)
+}
@@ -0,0 +1,137 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vcache
+
+import (
+	"container/heap"
+	"container/list"
+	"time"
+)
+
+type entry[K comparable, V any] struct {
+	key      K
+	value    V
+	expireAt time.Time
+
+	// element in the lru list.
+	elem *list.Element
+
+	// frequency, access sequence and index in the lfu heap.
+	freq  uint64
+	seq   uint64
+	index int
+}
+
+func (e *entry[K, V]) expired(now time.Time) bool {
+	return !e.expireAt.IsZero() && now.After(e.expireAt)
+}
+
+// evictor track the entries of a shard and choose the one to evict.
+type evictor[K comparable, V any] interface {
+	add(e *entry[K, V])
+	touch(e *entry[K, V])
+	remove(e *entry[K, V])
+	victim() *entry[K, V]
+}
+
+// lru the front is the most recently used.
+type lru[K comparable, V any] struct {
+	l *list.List
+}
+
+func newLRU[K comparable, V any]() *lru[K, V] {
+	return &lru[K, V]{l: list.New()}
+}
+
+func (p *lru[K, V]) add(e *entry[K, V]) {
+	e.elem = p.l.PushFront(e)
+}
+
+func (p *lru[K, V]) touch(e *entry[K, V]) {
+	p.l.MoveToFront(e.elem)
+}
+
+func (p *lru[K, V]) remove(e *entry[K, V]) {
+	p.l.Remove(e.elem)
+	e.elem = nil
+}
+
+func (p *lru[K, V]) victim() *entry[K, V] {
+	return p.l.Back().Value.(*entry[K, V])
+}
+
+// lfu a min heap ordered by frequency and access sequence.
+type lfu[K comparable, V any] struct {
+	entries []*entry[K, V]
+	seq     uint64
+}
+
+func (p *lfu[K, V]) Len() int { return len(p.entries) }
+
+func (p *lfu[K, V]) Less(i, j int) bool {
+	a, b := p.entries[i], p.entries[j]
+	if a.freq != b.freq {
+		return a.freq < b.freq
+	}
+
+	return a.seq < b.seq
+}
+
+func (p *lfu[K, V]) Swap(i, j int) {
+	p.entries[i], p.entries[j] = p.entries[j], p.entries[i]
+	p.entries[i].index = i
+	p.entries[j].index = j
+}
+
+func (p *lfu[K, V]) Push(x any) {
+	e := x.(*entry[K, V])
+	e.index = len(p.entries)
+	p.entries = append(p.entries, e)
+}
+
+func (p *lfu[K, V]) Pop() any {
+	n := len(p.entries)
+	e := p.entries[n-1]
+	p.entries[n-1] = nil
+	p.entries = p.entries[:n-1]
+	e.index = -1
+
+	return e
+}
+
+func (p *lfu[K, V]) add(e *entry[K, V]) {
+	p.seq++
+	e.freq = 1
+	e.seq = p.seq
+	heap.Push(p, e)
+}
+
+func (p *lfu[K, V]) touch(e *entry[K, V]) {
+	p.seq++
+	e.freq++
+	e.seq = p.seq
+	heap.Fix(p, e.index)
+}
+
+func (p *lfu[K, V]) remove(e *entry[K, V]) {
+	heap.Remove(p, e.index)
+}
+
+func (p *lfu[K, V]) victim() *entry[K, V] {
+	return p.entries[0]
+}
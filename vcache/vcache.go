@@ -0,0 +1,387 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package vcache provides a generic sharded cache with LRU or LFU eviction and per-entry TTL.
+//
+//	c := vcache.New[string, *User](
+//		vcache.WithCapacity(10000),
+//		vcache.WithTTL(time.Minute),
+//		vcache.WithExpiry(runner, time.Minute),
+//	)
+//	u, err := c.Load(ctx, id, loadUser)
+package vcache
+
+import (
+	"context"
+	"hash/maphash"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vogo/vogo/vsync"
+	"github.com/vogo/vogo/vsync/vrun"
+)
+
+const DefaultShards = 16
+
+// Policy the eviction policy when a shard is full.
+type Policy int
+
+const (
+	// LRU evict the least recently used entry.
+	LRU Policy = iota
+	// LFU evict the least frequently used entry, the least recently used one of them if tied.
+	LFU
+)
+
+// Loader load the value of a key missing in the cache.
+type Loader[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+type options struct {
+	capacity int
+	shards   int
+	policy   Policy
+	ttl      time.Duration
+	runner   *vrun.Runner
+	interval time.Duration
+}
+
+// Option the option of a cache.
+type Option func(o *options)
+
+// WithCapacity set the max count of the entries, no limit if it's not positive.
+// The capacity is split among the shards, so an entry may be evicted before the cache is full.
+func WithCapacity(capacity int) Option {
+	return func(o *options) {
+		o.capacity = capacity
+	}
+}
+
+// WithShards set the count of the shards, default DefaultShards.
+func WithShards(shards int) Option {
+	return func(o *options) {
+		o.shards = max(shards, 1)
+	}
+}
+
+// WithPolicy set the eviction policy, default LRU.
+func WithPolicy(policy Policy) Option {
+	return func(o *options) {
+		o.policy = policy
+	}
+}
+
+// WithTTL set the default TTL of the entries, no expiry if it's not positive.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithExpiry remove the expired entries at intervals until the runner is stopped.
+// Without it, the expired entries are only removed when they're accessed or evicted.
+func WithExpiry(runner *vrun.Runner, interval time.Duration) Option {
+	return func(o *options) {
+		o.runner = runner
+		o.interval = interval
+	}
+}
+
+// Stats the statistics of a cache.
+type Stats struct {
+	Hits        uint64
+	Misses      uint64
+	Evictions   uint64
+	Expirations uint64
+	Loads       uint64
+	LoadErrors  uint64
+}
+
+// Cache a generic sharded cache, which is safe for concurrent use.
+type Cache[K comparable, V any] struct {
+	opts   options
+	seed   maphash.Seed
+	shards []*shard[K, V]
+	flight vsync.Singleflight[K, V]
+
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+	loads       atomic.Uint64
+	loadErrors  atomic.Uint64
+}
+
+// New create a new cache.
+func New[K comparable, V any](opts ...Option) *Cache[K, V] {
+	o := options{
+		shards: DefaultShards,
+		policy: LRU,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	c := &Cache[K, V]{
+		opts:   o,
+		seed:   maphash.MakeSeed(),
+		shards: make([]*shard[K, V], o.shards),
+	}
+
+	capacity := 0
+	if o.capacity > 0 {
+		capacity = (o.capacity + o.shards - 1) / o.shards
+	}
+
+	for i := range c.shards {
+		c.shards[i] = newShard[K, V](capacity, o.policy)
+	}
+
+	if o.runner != nil && o.interval > 0 {
+		o.runner.Interval(c.RemoveExpired, o.interval)
+	}
+
+	return c
+}
+
+func (c *Cache[K, V]) shard(key K) *shard[K, V] {
+	if len(c.shards) == 1 {
+		return c.shards[0]
+	}
+
+	return c.shards[maphash.Comparable(c.seed, key)%uint64(len(c.shards))]
+}
+
+// Get returns the value of the key, and whether it's found and not expired.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	v, found, expired := c.shard(key).get(key, time.Now())
+
+	if expired {
+		c.expirations.Add(1)
+	}
+
+	if found {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+
+	return v, found
+}
+
+// Set set the value of the key with the default TTL.
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, c.opts.ttl)
+}
+
+// SetWithTTL set the value of the key with the TTL, no expiry if it's not positive.
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	var expireAt time.Time
+	if ttl > 0 {
+		expireAt = time.Now().Add(ttl)
+	}
+
+	if c.shard(key).set(key, value, expireAt) {
+		c.evictions.Add(1)
+	}
+}
+
+// Delete delete the key, returns whether it's found.
+func (c *Cache[K, V]) Delete(key K) bool {
+	return c.shard(key).delete(key)
+}
+
+// Load returns the value of the key, which is loaded by the loader and cached if missing.
+// The concurrent loads of the same key call the loader only once, and share its result.
+func (c *Cache[K, V]) Load(ctx context.Context, key K, loader Loader[K, V]) (V, error) {
+	if v, ok := c.Get(key); ok {
+		return v, nil
+	}
+
+	v, err, _ := c.flight.Do(key, func() (V, error) {
+		c.loads.Add(1)
+
+		v, err := loader(ctx, key)
+		if err != nil {
+			c.loadErrors.Add(1)
+			return v, err
+		}
+
+		c.Set(key, v)
+
+		return v, nil
+	})
+
+	return v, err
+}
+
+// RemoveExpired remove the expired entries.
+func (c *Cache[K, V]) RemoveExpired() {
+	now := time.Now()
+
+	for _, s := range c.shards {
+		c.expirations.Add(uint64(s.removeExpired(now)))
+	}
+}
+
+// Len returns the count of the entries, including the expired ones not removed yet.
+func (c *Cache[K, V]) Len() int {
+	n := 0
+
+	for _, s := range c.shards {
+		n += s.len()
+	}
+
+	return n
+}
+
+// Clear remove all entries.
+func (c *Cache[K, V]) Clear() {
+	for _, s := range c.shards {
+		s.clear()
+	}
+}
+
+// Stats returns the statistics of the cache.
+func (c *Cache[K, V]) Stats() Stats {
+	return Stats{
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		Evictions:   c.evictions.Load(),
+		Expirations: c.expirations.Load(),
+		Loads:       c.loads.Load(),
+		LoadErrors:  c.loadErrors.Load(),
+	}
+}
+
+type shard[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[K]*entry[K, V]
+	evictor  evictor[K, V]
+}
+
+func newShard[K comparable, V any](capacity int, policy Policy) *shard[K, V] {
+	s := &shard[K, V]{
+		capacity: capacity,
+		entries:  make(map[K]*entry[K, V]),
+	}
+
+	if policy == LFU {
+		s.evictor = &lfu[K, V]{}
+	} else {
+		s.evictor = newLRU[K, V]()
+	}
+
+	return s
+}
+
+func (s *shard[K, V]) get(key K, now time.Time) (value V, found, expired bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return value, false, false
+	}
+
+	if e.expired(now) {
+		s.remove(e)
+		return value, false, true
+	}
+
+	s.evictor.touch(e)
+
+	return e.value, true, false
+}
+
+// set returns whether an entry is evicted.
+func (s *shard[K, V]) set(key K, value V, expireAt time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok {
+		e.value = value
+		e.expireAt = expireAt
+		s.evictor.touch(e)
+
+		return false
+	}
+
+	evicted := false
+
+	if s.capacity > 0 && len(s.entries) >= s.capacity {
+		s.remove(s.evictor.victim())
+		evicted = true
+	}
+
+	e := &entry[K, V]{key: key, value: value, expireAt: expireAt}
+	s.entries[key] = e
+	s.evictor.add(e)
+
+	return evicted
+}
+
+func (s *shard[K, V]) delete(key K) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if ok {
+		s.remove(e)
+	}
+
+	return ok
+}
+
+func (s *shard[K, V]) remove(e *entry[K, V]) {
+	delete(s.entries, e.key)
+	s.evictor.remove(e)
+}
+
+func (s *shard[K, V]) removeExpired(now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+
+	for _, e := range s.entries {
+		if e.expired(now) {
+			s.remove(e)
+			n++
+		}
+	}
+
+	return n
+}
+
+func (s *shard[K, V]) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.entries)
+}
+
+func (s *shard[K, V]) clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.entries {
+		s.remove(e)
+	}
+}
@@ -0,0 +1,168 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vcache_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/vcache"
+	"github.com/vogo/vogo/vsync/vrun"
+)
+
+func TestLRU(t *testing.T) {
+	t.Parallel()
+
+	c := vcache.New[string, int](vcache.WithShards(1), vcache.WithCapacity(2))
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+
+	// b is the least recently used.
+	c.Set("c", 3)
+
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	assert.Equal(t, 2, c.Len())
+	assert.True(t, c.Delete("a"))
+	assert.False(t, c.Delete("a"))
+
+	stats := c.Stats()
+	assert.Equal(t, uint64(2), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, uint64(1), stats.Evictions)
+}
+
+func TestLFU(t *testing.T) {
+	t.Parallel()
+
+	c := vcache.New[string, int](vcache.WithShards(1), vcache.WithCapacity(2), vcache.WithPolicy(vcache.LFU))
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	for range 3 {
+		c.Get("b")
+	}
+
+	c.Get("a")
+
+	// a is the least frequently used.
+	c.Set("c", 3)
+
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+
+	_, ok = c.Get("b")
+	assert.True(t, ok)
+
+	// c is the least frequently used.
+	c.Set("d", 4)
+
+	_, ok = c.Get("c")
+	assert.False(t, ok)
+}
+
+func TestTTL(t *testing.T) {
+	t.Parallel()
+
+	runner := vrun.New()
+	defer runner.Stop()
+
+	c := vcache.New[string, int](vcache.WithTTL(10*time.Millisecond), vcache.WithExpiry(runner, 5*time.Millisecond))
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.SetWithTTL("c", 3, 0)
+
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+
+	time.Sleep(30 * time.Millisecond)
+
+	assert.Equal(t, 1, c.Len())
+	assert.Equal(t, uint64(2), c.Stats().Expirations)
+
+	_, ok = c.Get("c")
+	assert.True(t, ok)
+
+	c.Clear()
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	c := vcache.New[int, string]()
+
+	var (
+		loads int64
+		wg    sync.WaitGroup
+	)
+
+	loader := func(_ context.Context, key int) (string, error) {
+		atomic.AddInt64(&loads, 1)
+		time.Sleep(10 * time.Millisecond)
+
+		return strconv.Itoa(key), nil
+	}
+
+	for range 10 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			v, err := c.Load(context.Background(), 1, loader)
+			assert.NoError(t, err)
+			assert.Equal(t, "1", v)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int64(1), atomic.LoadInt64(&loads))
+
+	errLoad := errors.New("load error")
+
+	_, err := c.Load(context.Background(), 2, func(context.Context, int) (string, error) {
+		return "", errLoad
+	})
+	assert.ErrorIs(t, err, errLoad)
+
+	_, ok := c.Get(2)
+	assert.False(t, ok)
+
+	stats := c.Stats()
+	assert.Equal(t, uint64(2), stats.Loads)
+	assert.Equal(t, uint64(1), stats.LoadErrors)
+}
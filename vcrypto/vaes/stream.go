@@ -0,0 +1,239 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vaes
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	// DefaultChunkSize the plaintext size of each chunk in the streams.
+	DefaultChunkSize = 64 * 1024
+	// MaxChunkSize the max plaintext size of each chunk in the streams.
+	MaxChunkSize = 16 * 1024 * 1024
+
+	// saltSize the size of the random salt deriving the key of each stream by HKDF-SHA256.
+	saltSize = 32
+	// noncePrefixSize the random part of the chunk nonces, followed by the 4 bytes counter and the 1 byte last flag.
+	noncePrefixSize = 7
+	// streamHeaderSize version | chunk size | salt | nonce prefix.
+	streamHeaderSize = 1 + 4 + saltSize + noncePrefixSize
+	// saltOffset the offset of the salt in the header, followed by the nonce prefix.
+	saltOffset = 5
+
+	// streamKeyInfo the HKDF info deriving the stream keys.
+	streamKeyInfo = "vaes stream"
+)
+
+var ErrStreamTooLong = errors.New("stream too long")
+
+// EncryptStream encrypt src to dst in chunks of DefaultChunkSize, see EncryptStreamChunk.
+func EncryptStream(key []byte, dst io.Writer, src io.Reader, aad []byte) error {
+	return EncryptStreamChunk(key, dst, src, aad, DefaultChunkSize)
+}
+
+// EncryptStreamChunk encrypt src to dst in chunks of the plaintext size, so that large files are encrypted
+// in constant memory. Each stream is encrypted by a key derived from the key and a random salt by HKDF-SHA256,
+// so the nonces of the streams under the same key never collide in practice, like the streaming AEAD of Tink.
+// Each chunk is sealed with a nonce of a random prefix, the chunk index and a last flag,
+// so that reordered, dropped or truncated chunks are detected by DecryptStream.
+func EncryptStreamChunk(key []byte, dst io.Writer, src io.Reader, aad []byte, chunkSize int) error {
+	if chunkSize <= 0 || chunkSize > MaxChunkSize {
+		return fmt.Errorf("invalid chunk size: %d", chunkSize)
+	}
+
+	if _, err := aes.NewCipher(key); err != nil {
+		return err
+	}
+
+	header := make([]byte, streamHeaderSize)
+	header[0] = versionStream
+	binary.BigEndian.PutUint32(header[1:5], uint32(chunkSize))
+
+	if _, err := rand.Read(header[saltOffset:]); err != nil {
+		return err
+	}
+
+	gcm, err := newStreamGCM(key, header)
+	if err != nil {
+		return err
+	}
+
+	if _, err = dst.Write(header); err != nil {
+		return err
+	}
+
+	var (
+		ad    = additionalData(header, aad)
+		nonce = chunkNonce(gcm, header[saltOffset+saltSize:])
+		buf   = make([]byte, chunkSize+1)
+		out   = make([]byte, 0, chunkSize+gcm.Overhead())
+	)
+
+	// read one more byte to know whether the chunk is the last.
+	n, err := io.ReadFull(src, buf)
+
+	for index := uint64(0); ; index++ {
+		last := false
+
+		switch {
+		case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+			last = true
+		case err != nil:
+			return err
+		}
+
+		if index > math.MaxUint32 {
+			return ErrStreamTooLong
+		}
+
+		size := min(n, chunkSize)
+		setChunkNonce(nonce, uint32(index), last)
+
+		if _, err = dst.Write(gcm.Seal(out[:0], nonce, buf[:size], ad)); err != nil {
+			return err
+		}
+
+		if last {
+			return nil
+		}
+
+		// the extra byte is the first of the next chunk.
+		buf[0] = buf[chunkSize]
+		n, err = io.ReadFull(src, buf[1:])
+		n++
+
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+	}
+}
+
+// DecryptStream decrypt src of EncryptStream to dst. The chunks are written to dst once authenticated,
+// so the output must be discarded if an error is returned, e.g. ErrAuthFailed for a truncated stream.
+func DecryptStream(key []byte, dst io.Writer, src io.Reader, aad []byte) error {
+	if _, err := aes.NewCipher(key); err != nil {
+		return err
+	}
+
+	header := make([]byte, streamHeaderSize)
+	if _, err := io.ReadFull(src, header[:1]); err != nil {
+		return ErrCiphertextInvalid
+	}
+
+	if header[0] != versionStream {
+		return fmt.Errorf("%w: %d", ErrVersionUnsupported, header[0])
+	}
+
+	if _, err := io.ReadFull(src, header[1:]); err != nil {
+		return ErrCiphertextInvalid
+	}
+
+	chunkSize := int(binary.BigEndian.Uint32(header[1:5]))
+	if chunkSize <= 0 || chunkSize > MaxChunkSize {
+		return ErrCiphertextInvalid
+	}
+
+	gcm, err := newStreamGCM(key, header)
+	if err != nil {
+		return err
+	}
+
+	var (
+		ad     = additionalData(header, aad)
+		nonce  = chunkNonce(gcm, header[saltOffset+saltSize:])
+		reader = bufio.NewReader(src)
+		buf    = make([]byte, chunkSize+gcm.Overhead())
+		out    = make([]byte, 0, chunkSize)
+	)
+
+	for index := uint64(0); ; index++ {
+		if index > math.MaxUint32 {
+			return ErrStreamTooLong
+		}
+
+		n, err := io.ReadFull(reader, buf)
+
+		last := false
+
+		switch {
+		case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+			last = true
+		case err != nil:
+			return err
+		default:
+			if _, err = reader.Peek(1); errors.Is(err, io.EOF) {
+				last = true
+			} else if err != nil {
+				return err
+			}
+		}
+
+		setChunkNonce(nonce, uint32(index), last)
+
+		plaintext, err := gcm.Open(out[:0], nonce, buf[:n], ad)
+		if err != nil {
+			return ErrAuthFailed
+		}
+
+		if _, err = dst.Write(plaintext); err != nil {
+			return err
+		}
+
+		if last {
+			return nil
+		}
+	}
+}
+
+// newStreamGCM derive the key of the stream from the salt in the header, which has the same size as the key.
+func newStreamGCM(key, header []byte) (cipher.AEAD, error) {
+	streamKey, err := hkdf.Key(sha256.New, key, header[saltOffset:saltOffset+saltSize], streamKeyInfo, len(key))
+	if err != nil {
+		return nil, err
+	}
+
+	return newGCM(streamKey)
+}
+
+func chunkNonce(gcm cipher.AEAD, prefix []byte) []byte {
+	nonce := make([]byte, gcm.NonceSize())
+	copy(nonce, prefix)
+
+	return nonce
+}
+
+func setChunkNonce(nonce []byte, index uint32, last bool) {
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], index)
+
+	if last {
+		nonce[noncePrefixSize+4] = 1
+	} else {
+		nonce[noncePrefixSize+4] = 0
+	}
+}
@@ -0,0 +1,113 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vaes_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/vcrypto/vaes"
+)
+
+// headerSize version | chunk size | salt | nonce prefix.
+const headerSize = 1 + 4 + 32 + 7
+
+func TestStream(t *testing.T) {
+	t.Parallel()
+
+	key, err := vaes.GenerateKey()
+	assert.NoError(t, err)
+
+	aad := []byte("file.log")
+
+	for _, size := range []int{0, 1, 99, 100, 101, 250, 1000} {
+		plaintext := bytes.Repeat([]byte("x"), size)
+
+		var encrypted bytes.Buffer
+		assert.NoError(t, vaes.EncryptStreamChunk(key, &encrypted, bytes.NewReader(plaintext), aad, 100))
+
+		chunks := max((size+99)/100, 1)
+		assert.Equal(t, headerSize+size+chunks*16, encrypted.Len(), "size %d", size)
+
+		var decrypted bytes.Buffer
+		assert.NoError(t, vaes.DecryptStream(key, &decrypted, bytes.NewReader(encrypted.Bytes()), aad))
+		assert.Equal(t, string(plaintext), decrypted.String())
+
+		// truncated at a chunk boundary.
+		if size > 100 {
+			truncated := encrypted.Bytes()[:headerSize+100+16]
+			err = vaes.DecryptStream(key, &bytes.Buffer{}, bytes.NewReader(truncated), aad)
+			assert.ErrorIs(t, err, vaes.ErrAuthFailed)
+		}
+
+		err = vaes.DecryptStream(key, &bytes.Buffer{}, bytes.NewReader(encrypted.Bytes()), nil)
+		assert.ErrorIs(t, err, vaes.ErrAuthFailed)
+	}
+
+	var encrypted bytes.Buffer
+	assert.NoError(t, vaes.EncryptStream(key, &encrypted, bytes.NewReader([]byte("hello")), nil))
+
+	// the formats are not interchangeable.
+	_, err = vaes.Decrypt(key, encrypted.Bytes(), nil)
+	assert.ErrorIs(t, err, vaes.ErrVersionUnsupported)
+
+	assert.Error(t, vaes.EncryptStreamChunk(key, &encrypted, bytes.NewReader(nil), nil, 0))
+}
+
+func TestStreamReorder(t *testing.T) {
+	t.Parallel()
+
+	key, err := vaes.GenerateKey()
+	assert.NoError(t, err)
+
+	var encrypted bytes.Buffer
+	assert.NoError(t, vaes.EncryptStreamChunk(key, &encrypted, bytes.NewReader(bytes.Repeat([]byte("ab"), 150)), nil, 100))
+
+	b := encrypted.Bytes()
+	chunk := 100 + 16
+	first := bytes.Clone(b[headerSize : headerSize+chunk])
+	copy(b[headerSize:], b[headerSize+chunk:headerSize+2*chunk])
+	copy(b[headerSize+chunk:], first)
+
+	err = vaes.DecryptStream(key, &bytes.Buffer{}, bytes.NewReader(b), nil)
+	assert.ErrorIs(t, err, vaes.ErrAuthFailed)
+}
+
+func TestStreamKeys(t *testing.T) {
+	t.Parallel()
+
+	key, err := vaes.GenerateKey()
+	assert.NoError(t, err)
+
+	plaintext := []byte("the same plaintext")
+
+	var first, second bytes.Buffer
+	assert.NoError(t, vaes.EncryptStream(key, &first, bytes.NewReader(plaintext), nil))
+	assert.NoError(t, vaes.EncryptStream(key, &second, bytes.NewReader(plaintext), nil))
+
+	// the streams are encrypted by different keys derived from the random salts.
+	assert.NotEqual(t, first.Bytes()[5:37], second.Bytes()[5:37])
+	assert.NotEqual(t, first.Bytes()[headerSize:], second.Bytes()[headerSize:])
+
+	// the salt is authenticated.
+	tampered := bytes.Clone(first.Bytes())
+	tampered[5] ^= 1
+	err = vaes.DecryptStream(key, &bytes.Buffer{}, bytes.NewReader(tampered), nil)
+	assert.ErrorIs(t, err, vaes.ErrAuthFailed)
+}
@@ -0,0 +1,165 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package vaes provides AES-GCM authenticated encryption in a versioned format.
+//
+//	key, _ := vaes.GenerateKey()
+//	ciphertext, _ := vaes.Encrypt(key, plaintext, nil)
+//	plaintext, _ := vaes.Decrypt(key, ciphertext, nil)
+package vaes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+const (
+	// KeySize the size of the keys generated, for AES-256.
+	KeySize = 32
+
+	// versionGCM the format of Encrypt: version | nonce | ciphertext with tag.
+	versionGCM byte = 1
+	// versionStream the format of EncryptStream, see stream.go.
+	versionStream byte = 2
+)
+
+var (
+	ErrCiphertextInvalid  = errors.New("ciphertext invalid")
+	ErrVersionUnsupported = errors.New("ciphertext version unsupported")
+	// ErrAuthFailed the ciphertext, the associated data or the key is wrong, the ciphertext may be tampered.
+	ErrAuthFailed = errors.New("message authentication failed")
+)
+
+// GenerateKey generate a random AES-256 key.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// GenerateKey64 generate a random AES-256 key encoded in base64.
+func GenerateKey64() (string, error) {
+	key, err := GenerateKey()
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// KeyFrom64 decode the base64 key, which must be 16, 24 or 32 bytes for AES-128, AES-192 or AES-256.
+func KeyFrom64(key string) ([]byte, error) {
+	b, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = aes.NewCipher(b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// additionalData the versioned header is authenticated with the associated data.
+func additionalData(header, aad []byte) []byte {
+	return append(header[:len(header):len(header)], aad...)
+}
+
+// Encrypt encrypt the plaintext with a random nonce, the associated data is authenticated but not encrypted,
+// and must be the same for Decrypt.
+func Encrypt(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 1+gcm.NonceSize(), 1+gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	out[0] = versionGCM
+
+	if _, err = rand.Read(out[1:]); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(out, out[1:], plaintext, additionalData(out[:1], aad)), nil
+}
+
+// Decrypt decrypt the ciphertext of Encrypt, returns ErrAuthFailed if the key or associated data is wrong
+// or the ciphertext is tampered.
+func Decrypt(key, ciphertext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) == 0 {
+		return nil, ErrCiphertextInvalid
+	}
+
+	if ciphertext[0] != versionGCM {
+		return nil, fmt.Errorf("%w: %d", ErrVersionUnsupported, ciphertext[0])
+	}
+
+	if len(ciphertext) < 1+gcm.NonceSize()+gcm.Overhead() {
+		return nil, ErrCiphertextInvalid
+	}
+
+	nonce := ciphertext[1 : 1+gcm.NonceSize()]
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext[1+gcm.NonceSize():], additionalData(ciphertext[:1], aad))
+	if err != nil {
+		return nil, ErrAuthFailed
+	}
+
+	return plaintext, nil
+}
+
+// Encrypt64 encrypt the plaintext by Encrypt and encode the ciphertext in base64.
+func Encrypt64(key, plaintext, aad []byte) (string, error) {
+	b, err := Encrypt(key, plaintext, aad)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// Decrypt64 decrypt the base64 ciphertext of Encrypt64.
+func Decrypt64(key []byte, ciphertext64 string, aad []byte) ([]byte, error) {
+	b, err := base64.StdEncoding.DecodeString(ciphertext64)
+	if err != nil {
+		return nil, err
+	}
+
+	return Decrypt(key, b, aad)
+}
@@ -0,0 +1,88 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vaes_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/vcrypto/vaes"
+)
+
+func TestEncrypt(t *testing.T) {
+	t.Parallel()
+
+	key, err := vaes.GenerateKey()
+	assert.NoError(t, err)
+	assert.Len(t, key, vaes.KeySize)
+
+	plaintext := []byte("hello world")
+	aad := []byte("user:1")
+
+	ciphertext, err := vaes.Encrypt(key, plaintext, aad)
+	assert.NoError(t, err)
+
+	decrypted, err := vaes.Decrypt(key, ciphertext, aad)
+	assert.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	// random nonces.
+	other, err := vaes.Encrypt(key, plaintext, aad)
+	assert.NoError(t, err)
+	assert.NotEqual(t, ciphertext, other)
+
+	_, err = vaes.Decrypt(key, ciphertext, []byte("user:2"))
+	assert.ErrorIs(t, err, vaes.ErrAuthFailed)
+
+	tampered := bytes.Clone(ciphertext)
+	tampered[len(tampered)-1] ^= 1
+	_, err = vaes.Decrypt(key, tampered, aad)
+	assert.ErrorIs(t, err, vaes.ErrAuthFailed)
+
+	tampered = bytes.Clone(ciphertext)
+	tampered[0] = 9
+	_, err = vaes.Decrypt(key, tampered, aad)
+	assert.ErrorIs(t, err, vaes.ErrVersionUnsupported)
+
+	_, err = vaes.Decrypt(key, ciphertext[:10], aad)
+	assert.ErrorIs(t, err, vaes.ErrCiphertextInvalid)
+
+	_, err = vaes.Encrypt([]byte("short"), plaintext, nil)
+	assert.Error(t, err)
+}
+
+func TestEncrypt64(t *testing.T) {
+	t.Parallel()
+
+	key64, err := vaes.GenerateKey64()
+	assert.NoError(t, err)
+
+	key, err := vaes.KeyFrom64(key64)
+	assert.NoError(t, err)
+
+	ciphertext64, err := vaes.Encrypt64(key, []byte("hello"), nil)
+	assert.NoError(t, err)
+
+	plaintext, err := vaes.Decrypt64(key, ciphertext64, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(plaintext))
+
+	_, err = vaes.KeyFrom64("c2hvcnQ=")
+	assert.Error(t, err)
+}
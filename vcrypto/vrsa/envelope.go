@@ -0,0 +1,138 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vrsa
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/vogo/vogo/vcrypto/vaes"
+)
+
+// envelopeVersion the format of envelopes: version | wrapped key size (2 bytes) | wrapped key | vaes ciphertext.
+// The AES-256 key is wrapped by RSA-OAEP with SHA-256, and the header is authenticated as the associated data of vaes.
+const envelopeVersion byte = 1
+
+var (
+	ErrEnvelopeInvalid            = errors.New("envelope invalid")
+	ErrEnvelopeVersionUnsupported = errors.New("envelope version unsupported")
+)
+
+// envelopeHeader generate a random AES key and returns it with the header wrapping it by the public key.
+func envelopeHeader(key *rsa.PublicKey) (aesKey, header []byte, err error) {
+	aesKey, err = vaes.GenerateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wrapped, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, key, aesKey, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header = make([]byte, 3, 3+len(wrapped))
+	header[0] = envelopeVersion
+	binary.BigEndian.PutUint16(header[1:3], uint16(len(wrapped)))
+
+	return aesKey, append(header, wrapped...), nil
+}
+
+// readEnvelopeHeader read the header and returns the AES key unwrapped by the private key with the header.
+func readEnvelopeHeader(key *rsa.PrivateKey, r io.Reader) (aesKey, header []byte, err error) {
+	header = make([]byte, 3)
+	if _, err = io.ReadFull(r, header); err != nil {
+		return nil, nil, ErrEnvelopeInvalid
+	}
+
+	if header[0] != envelopeVersion {
+		return nil, nil, fmt.Errorf("%w: %d", ErrEnvelopeVersionUnsupported, header[0])
+	}
+
+	size := int(binary.BigEndian.Uint16(header[1:3]))
+	if size != key.Size() {
+		return nil, nil, ErrEnvelopeInvalid
+	}
+
+	header = append(header, make([]byte, size)...)
+	if _, err = io.ReadFull(r, header[3:]); err != nil {
+		return nil, nil, ErrEnvelopeInvalid
+	}
+
+	aesKey, err = rsa.DecryptOAEP(sha256.New(), rand.Reader, key, header[3:], nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return aesKey, header, nil
+}
+
+// EnvelopeEncrypt encrypt data of any size by a random AES-GCM key, which is encrypted by the public key with RSA-OAEP.
+func EnvelopeEncrypt(key *rsa.PublicKey, data []byte) ([]byte, error) {
+	aesKey, header, err := envelopeHeader(key)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := vaes.Encrypt(aesKey, data, header)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(header, ciphertext...), nil
+}
+
+// EnvelopeDecrypt decrypt the envelope of EnvelopeEncrypt by the private key.
+func EnvelopeDecrypt(key *rsa.PrivateKey, data []byte) ([]byte, error) {
+	aesKey, header, err := readEnvelopeHeader(key, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	return vaes.Decrypt(aesKey, data[len(header):], header)
+}
+
+// EnvelopeEncryptStream encrypt src to dst like EnvelopeEncrypt, in chunks by vaes.EncryptStream.
+func EnvelopeEncryptStream(key *rsa.PublicKey, dst io.Writer, src io.Reader) error {
+	aesKey, header, err := envelopeHeader(key)
+	if err != nil {
+		return err
+	}
+
+	if _, err = dst.Write(header); err != nil {
+		return err
+	}
+
+	return vaes.EncryptStream(aesKey, dst, src, header)
+}
+
+// EnvelopeDecryptStream decrypt src of EnvelopeEncryptStream to dst by the private key,
+// the output must be discarded if an error is returned, see vaes.DecryptStream.
+func EnvelopeDecryptStream(key *rsa.PrivateKey, dst io.Writer, src io.Reader) error {
+	aesKey, header, err := readEnvelopeHeader(key, src)
+	if err != nil {
+		return err
+	}
+
+	return vaes.DecryptStream(aesKey, dst, src, header)
+}
@@ -0,0 +1,95 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vrsa_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/vcrypto/vaes"
+	"github.com/vogo/vogo/vcrypto/vrsa"
+)
+
+func TestEnvelope(t *testing.T) {
+	t.Parallel()
+
+	pri64, pub64, err := vrsa.GenerateKey64()
+	assert.NoError(t, err)
+
+	pri, err := vrsa.PrivateKeyFrom64(pri64)
+	assert.NoError(t, err)
+
+	pub, err := vrsa.PublicKeyFrom64(pub64)
+	assert.NoError(t, err)
+
+	// much longer than the limit of PublicEncrypt.
+	data := bytes.Repeat([]byte("payload"), 10000)
+
+	envelope, err := vrsa.EnvelopeEncrypt(pub, data)
+	assert.NoError(t, err)
+
+	decrypted, err := vrsa.EnvelopeDecrypt(pri, envelope)
+	assert.NoError(t, err)
+	assert.Equal(t, data, decrypted)
+
+	// the wrapped key is authenticated.
+	tampered := bytes.Clone(envelope)
+	tampered[3] ^= 1
+	_, err = vrsa.EnvelopeDecrypt(pri, tampered)
+	assert.Error(t, err)
+
+	tampered = bytes.Clone(envelope)
+	tampered[len(tampered)-1] ^= 1
+	_, err = vrsa.EnvelopeDecrypt(pri, tampered)
+	assert.ErrorIs(t, err, vaes.ErrAuthFailed)
+
+	tampered = bytes.Clone(envelope)
+	tampered[0] = 9
+	_, err = vrsa.EnvelopeDecrypt(pri, tampered)
+	assert.ErrorIs(t, err, vrsa.ErrEnvelopeVersionUnsupported)
+
+	_, err = vrsa.EnvelopeDecrypt(pri, envelope[:100])
+	assert.ErrorIs(t, err, vrsa.ErrEnvelopeInvalid)
+
+	other, _, err := vrsa.GenerateKey()
+	assert.NoError(t, err)
+
+	_, err = vrsa.EnvelopeDecrypt(other, envelope)
+	assert.Error(t, err)
+}
+
+func TestEnvelopeStream(t *testing.T) {
+	t.Parallel()
+
+	pri, pub, err := vrsa.GenerateKey()
+	assert.NoError(t, err)
+
+	data := bytes.Repeat([]byte("stream"), 50000)
+
+	var encrypted bytes.Buffer
+	assert.NoError(t, vrsa.EnvelopeEncryptStream(pub, &encrypted, bytes.NewReader(data)))
+
+	var decrypted bytes.Buffer
+	assert.NoError(t, vrsa.EnvelopeDecryptStream(pri, &decrypted, bytes.NewReader(encrypted.Bytes())))
+	assert.Equal(t, data, decrypted.Bytes())
+
+	truncated := encrypted.Bytes()[:encrypted.Len()-10]
+	err = vrsa.EnvelopeDecryptStream(pri, &bytes.Buffer{}, bytes.NewReader(truncated))
+	assert.ErrorIs(t, err, vaes.ErrAuthFailed)
+}
@@ -0,0 +1,196 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vrsa
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha256" // register SHA-224 and SHA-256
+	_ "crypto/sha512" // register SHA-384 and SHA-512
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrHashUnavailable = errors.New("hash unavailable")
+	// ErrOptionInvalid an option not applicable to the operation, e.g. WithPSS for encryption.
+	ErrOptionInvalid = errors.New("option invalid")
+)
+
+// operation the kind of the functions using the options.
+type operation int
+
+const (
+	opEncrypt operation = iota
+	opSign
+)
+
+type options struct {
+	oaep  bool
+	pss   bool
+	hash  crypto.Hash
+	label []byte
+}
+
+// Option the option of the encryption and signature functions.
+type Option func(o *options)
+
+// WithOAEP encrypt by RSA-OAEP instead of PKCS#1 v1.5, which is invalid for signatures.
+func WithOAEP() Option {
+	return func(o *options) {
+		o.oaep = true
+	}
+}
+
+// WithPSS sign by RSA-PSS instead of PKCS#1 v1.5, the salt length equals the hash size, which is invalid for encryption.
+func WithPSS() Option {
+	return func(o *options) {
+		o.pss = true
+	}
+}
+
+// WithHash set the hash of OAEP and the signatures, e.g. crypto.SHA256, crypto.SHA384 or crypto.SHA512,
+// default crypto.SHA256 for OAEP and PSS, and crypto.SHA1 for PKCS#1 v1.5 signatures as PrivateSign.
+// It's invalid for PKCS#1 v1.5 encryption, which uses no hash.
+func WithHash(hash crypto.Hash) Option {
+	return func(o *options) {
+		o.hash = hash
+	}
+}
+
+// WithLabel set the label of OAEP, which must be the same for encryption and decryption, and is invalid without WithOAEP.
+func WithLabel(label []byte) Option {
+	return func(o *options) {
+		o.label = label
+	}
+}
+
+// newOptions apply the options for the operation, the default hash depends on the mode of the operation only.
+func newOptions(op operation, opts []Option) (*options, error) {
+	o := &options{}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	switch op {
+	case opEncrypt:
+		if o.pss {
+			return nil, fmt.Errorf("%w: PSS for encryption", ErrOptionInvalid)
+		}
+
+		if !o.oaep {
+			if o.label != nil {
+				return nil, fmt.Errorf("%w: label without OAEP", ErrOptionInvalid)
+			}
+
+			// PKCS#1 v1.5 encryption uses no hash.
+			if o.hash != 0 {
+				return nil, fmt.Errorf("%w: hash without OAEP", ErrOptionInvalid)
+			}
+
+			return o, nil
+		}
+	case opSign:
+		if o.oaep {
+			return nil, fmt.Errorf("%w: OAEP for signature", ErrOptionInvalid)
+		}
+
+		if o.label != nil {
+			return nil, fmt.Errorf("%w: label for signature", ErrOptionInvalid)
+		}
+	}
+
+	if o.hash == 0 {
+		if o.oaep || o.pss {
+			o.hash = crypto.SHA256
+		} else {
+			o.hash = crypto.SHA1
+		}
+	}
+
+	if !o.hash.Available() {
+		return nil, fmt.Errorf("%w: %v", ErrHashUnavailable, o.hash)
+	}
+
+	return o, nil
+}
+
+func (o *options) digest(data []byte) []byte {
+	h := o.hash.New()
+	h.Write(data)
+
+	return h.Sum(nil)
+}
+
+// PublicEncryptWithOptions encrypt data by the public key, PKCS#1 v1.5 as PublicEncrypt by default, or OAEP WithOAEP.
+func PublicEncryptWithOptions(key *rsa.PublicKey, data []byte, opts ...Option) ([]byte, error) {
+	o, err := newOptions(opEncrypt, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.oaep {
+		return rsa.EncryptOAEP(o.hash.New(), rand.Reader, key, data, o.label)
+	}
+
+	return rsa.EncryptPKCS1v15(rand.Reader, key, data)
+}
+
+// PrivateDecryptWithOptions decrypt data by the private key, the options must be the same as the encryption.
+func PrivateDecryptWithOptions(key *rsa.PrivateKey, data []byte, opts ...Option) ([]byte, error) {
+	o, err := newOptions(opEncrypt, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.oaep {
+		return rsa.DecryptOAEP(o.hash.New(), rand.Reader, key, data, o.label)
+	}
+
+	return rsa.DecryptPKCS1v15(rand.Reader, key, data)
+}
+
+// PrivateSignWithOptions sign the hash of data by the private key, PKCS#1 v1.5 as PrivateSign by default, or PSS WithPSS.
+func PrivateSignWithOptions(key *rsa.PrivateKey, data []byte, opts ...Option) ([]byte, error) {
+	o, err := newOptions(opSign, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.pss {
+		return rsa.SignPSS(rand.Reader, key, o.hash, o.digest(data), &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	}
+
+	return rsa.SignPKCS1v15(rand.Reader, key, o.hash, o.digest(data))
+}
+
+// PublicVerifyWithOptions verify the signature of data by the public key, the options must be the same as the signing.
+func PublicVerifyWithOptions(key *rsa.PublicKey, sign, data []byte, opts ...Option) error {
+	o, err := newOptions(opSign, opts)
+	if err != nil {
+		return err
+	}
+
+	if o.pss {
+		return rsa.VerifyPSS(key, o.hash, o.digest(data), sign, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	}
+
+	return rsa.VerifyPKCS1v15(key, o.hash, o.digest(data), sign)
+}
@@ -0,0 +1,118 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vrsa_test
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/vcrypto/vrsa"
+)
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	pri, pub, err := vrsa.GenerateKey()
+	assert.NoError(t, err)
+
+	data := []byte("hello world")
+
+	// the defaults are compatible with the PKCS#1 v1.5 functions.
+	enc, err := vrsa.PublicEncryptWithOptions(pub, data)
+	assert.NoError(t, err)
+
+	dec, err := vrsa.PrivateDecrypt(pri, enc)
+	assert.NoError(t, err)
+	assert.Equal(t, data, dec)
+
+	sign, err := vrsa.PrivateSign(pri, data)
+	assert.NoError(t, err)
+	assert.NoError(t, vrsa.PublicVerifyWithOptions(pub, sign, data))
+
+	for _, hash := range []crypto.Hash{0, crypto.SHA256, crypto.SHA384, crypto.SHA512} {
+		oaep := []vrsa.Option{vrsa.WithOAEP(), vrsa.WithHash(hash), vrsa.WithLabel([]byte("label"))}
+
+		enc, err = vrsa.PublicEncryptWithOptions(pub, data, oaep...)
+		assert.NoError(t, err)
+
+		dec, err = vrsa.PrivateDecryptWithOptions(pri, enc, oaep...)
+		assert.NoError(t, err)
+		assert.Equal(t, data, dec)
+
+		_, err = vrsa.PrivateDecryptWithOptions(pri, enc, vrsa.WithOAEP(), vrsa.WithHash(hash))
+		assert.Error(t, err)
+
+		for _, pss := range []bool{false, true} {
+			opts := []vrsa.Option{vrsa.WithHash(hash)}
+			if pss {
+				opts = append(opts, vrsa.WithPSS())
+			}
+
+			sign, err = vrsa.PrivateSignWithOptions(pri, data, opts...)
+			assert.NoError(t, err)
+			assert.NoError(t, vrsa.PublicVerifyWithOptions(pub, sign, data, opts...))
+			assert.ErrorIs(t, vrsa.PublicVerifyWithOptions(pub, sign, []byte("other"), opts...), rsa.ErrVerification)
+		}
+	}
+
+	pssSign, err := vrsa.PrivateSignWithOptions(pri, data, vrsa.WithPSS())
+	assert.NoError(t, err)
+	assert.Error(t, vrsa.PublicVerify(pub, pssSign, data))
+
+	_, err = vrsa.PrivateSignWithOptions(pri, data, vrsa.WithHash(crypto.MD4))
+	assert.ErrorIs(t, err, vrsa.ErrHashUnavailable)
+
+	// the default of signatures is SHA-1 as PrivateSign.
+	sign, err = vrsa.PrivateSignWithOptions(pri, data)
+	assert.NoError(t, err)
+	assert.NoError(t, vrsa.PublicVerify(pub, sign, data))
+}
+
+func TestOptionsInvalid(t *testing.T) {
+	t.Parallel()
+
+	pri, pub, err := vrsa.GenerateKey()
+	assert.NoError(t, err)
+
+	data := []byte("hello world")
+
+	for _, opts := range [][]vrsa.Option{
+		{vrsa.WithPSS()},
+		{vrsa.WithLabel([]byte("label"))},
+		{vrsa.WithHash(crypto.SHA256)},
+	} {
+		_, err = vrsa.PublicEncryptWithOptions(pub, data, opts...)
+		assert.ErrorIs(t, err, vrsa.ErrOptionInvalid)
+
+		_, err = vrsa.PrivateDecryptWithOptions(pri, data, opts...)
+		assert.ErrorIs(t, err, vrsa.ErrOptionInvalid)
+	}
+
+	for _, opts := range [][]vrsa.Option{
+		{vrsa.WithOAEP()},
+		{vrsa.WithLabel([]byte("label"))},
+		{vrsa.WithPSS(), vrsa.WithLabel([]byte("label"))},
+	} {
+		_, err = vrsa.PrivateSignWithOptions(pri, data, opts...)
+		assert.ErrorIs(t, err, vrsa.ErrOptionInvalid)
+
+		assert.ErrorIs(t, vrsa.PublicVerifyWithOptions(pub, data, data, opts...), vrsa.ErrOptionInvalid)
+	}
+}
@@ -75,6 +75,12 @@ var defaultHTTPClient = &http.Client{
 	Timeout: DefaultRequestTimeout,
 }
 
+// WrapTransport wraps the transport of the client used by ParseGet and ParsePost,
+// e.g. to limit the rate of outbound calls. It should be called before sending any request.
+func WrapTransport(wrap func(http.RoundTripper) http.RoundTripper) {
+	defaultHTTPClient.Transport = wrap(defaultHTTPClient.Transport)
+}
+
 // DownloadFile will download a url to a local file. It's efficient because it will
 // write as it downloads and not load the whole file into memory.
 func DownloadFile(filePath, rawURL string, timeout time.Duration) error {
@@ -0,0 +1,118 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vhttpbreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/vogo/vogo/vnet/vhttp"
+	"github.com/vogo/vogo/vsync/vbreaker"
+)
+
+// ErrServerError the error to count a response with a 5xx status as a failure.
+var ErrServerError = errors.New("http server error")
+
+// IsFailure classifies connection errors, timeouts and server errors as failures.
+func IsFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if vhttp.IsConnectionError(err) ||
+		errors.Is(err, ErrServerError) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// Transport a round tripper which maintains a circuit breaker for each host.
+type Transport struct {
+	next http.RoundTripper
+	cfg  vbreaker.Config
+
+	mu       sync.Mutex
+	breakers map[string]*vbreaker.Breaker
+}
+
+// NewTransport create a transport which breaks the circuit per host, the breakers are named by the host.
+// IsFailure is used if cfg.IsFailure is nil.
+// It can be installed into the vhttp client by vhttp.WrapTransport.
+func NewTransport(next http.RoundTripper, cfg vbreaker.Config) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = IsFailure
+	}
+
+	return &Transport{
+		next:     next,
+		cfg:      cfg,
+		breakers: make(map[string]*vbreaker.Breaker),
+	}
+}
+
+// Breaker returns the circuit breaker of the host.
+func (t *Transport) Breaker(host string) *vbreaker.Breaker {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b, ok := t.breakers[host]
+	if !ok {
+		b = vbreaker.New(host, t.cfg)
+		t.breakers[host] = b
+	}
+
+	return b
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	done, err := t.Breaker(req.URL.Host).Allow()
+	if err != nil {
+		// a round tripper must always close the body.
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+
+		return nil, fmt.Errorf("%w: host %s", err, req.URL.Host)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		done(err)
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		done(fmt.Errorf("%w: status %d", ErrServerError, resp.StatusCode))
+	} else {
+		done(nil)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,98 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vhttpbreaker_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/vnet/vhttp/vhttpbreaker"
+	"github.com/vogo/vogo/vsync/vbreaker"
+)
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	transport := vhttpbreaker.NewTransport(nil, vbreaker.Config{
+		ConsecutiveFailures: 2,
+		Cooldown:            time.Minute,
+	})
+	client := &http.Client{Transport: transport}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+		_ = resp.Body.Close()
+	}
+
+	u, _ := url.Parse(server.URL)
+	assert.Equal(t, vbreaker.StateOpen, transport.Breaker(u.Host).State())
+
+	_, err := client.Get(server.URL) //nolint:bodyclose // error expected
+	assert.ErrorIs(t, err, vbreaker.ErrOpen)
+
+	// connection errors are failures too.
+	server.Close()
+
+	_, err = http.Get(server.URL) //nolint:bodyclose // error expected
+	assert.True(t, vhttpbreaker.IsFailure(err))
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestTransportClosesBody(t *testing.T) {
+	t.Parallel()
+
+	transport := vhttpbreaker.NewTransport(nil, vbreaker.Config{
+		ConsecutiveFailures: 1,
+		Cooldown:            time.Minute,
+	})
+
+	done, err := transport.Breaker("example.com").Allow()
+	assert.NoError(t, err)
+	done(vhttpbreaker.ErrServerError)
+
+	body := &closeRecorder{Reader: strings.NewReader("data")}
+
+	req, err := http.NewRequest(http.MethodPost, "http://example.com/", body)
+	assert.NoError(t, err)
+
+	_, err = transport.RoundTrip(req) //nolint:bodyclose // error expected
+	assert.ErrorIs(t, err, vbreaker.ErrOpen)
+	assert.True(t, body.closed)
+}
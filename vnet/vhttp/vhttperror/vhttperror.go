@@ -22,6 +22,8 @@ import "net/http"
 const (
 	CodeOK                 = 0
 	CodeUnknownErr         = 10
+	CodeBadGatewayErr      = 11
+	CodeUnavailableErr     = 12
 	CodeUnauthenticatedErr = 20
 	CodeUnauthorizedErr    = 21
 	CodeForbiddenErr       = 22
@@ -29,6 +31,7 @@ const (
 	CodeNotFoundErr        = 101
 	CodeArgRequiredErr     = 102
 	CodeValueInvalidErr    = 103
+	CodeTooManyRequestsErr = 104
 )
 
 var (
@@ -39,6 +42,9 @@ var (
 	ErrUnauthenticated = NewStatusCodeError(http.StatusUnauthorized, CodeUnauthenticatedErr, "unauthenticated")
 	ErrUnauthorized    = NewStatusCodeError(http.StatusUnauthorized, CodeUnauthorizedErr, "unauthorized")
 	ErrForbidden       = NewStatusCodeError(http.StatusForbidden, CodeForbiddenErr, "forbidden")
+	ErrTooManyRequests = NewStatusCodeError(http.StatusTooManyRequests, CodeTooManyRequestsErr, "too many requests")
+	ErrBadGateway      = NewStatusCodeError(http.StatusBadGateway, CodeBadGatewayErr, "bad gateway")
+	ErrUnavailable     = NewStatusCodeError(http.StatusServiceUnavailable, CodeUnavailableErr, "service unavailable")
 )
 
 type Coder interface {
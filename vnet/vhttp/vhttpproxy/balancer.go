@@ -0,0 +1,168 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vhttpproxy
+
+import (
+	"hash/crc32"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/vogo/vogo/vnet/vhttp"
+)
+
+// Upstream an upstream server of the proxy.
+type Upstream struct {
+	URL *url.URL
+
+	unhealthy atomic.Bool
+	conns     atomic.Int64
+}
+
+// Healthy whether the upstream passed the latest health check.
+func (u *Upstream) Healthy() bool {
+	return !u.unhealthy.Load()
+}
+
+// Conns returns the count of active requests to the upstream.
+func (u *Upstream) Conns() int64 {
+	return u.conns.Load()
+}
+
+// Balancer chooses an upstream for a request.
+// A balancer holds the state of the upstreams, so it should not be shared among proxies.
+type Balancer interface {
+	// Pick choose one of the healthy upstreams, returns nil if none is healthy.
+	// The upstreams are always the same list of the proxy.
+	Pick(req *http.Request, upstreams []*Upstream) *Upstream
+}
+
+type roundRobin struct {
+	next atomic.Uint64
+}
+
+// RoundRobin returns a balancer choosing healthy upstreams in turn.
+func RoundRobin() Balancer {
+	return &roundRobin{}
+}
+
+func (b *roundRobin) Pick(_ *http.Request, upstreams []*Upstream) *Upstream {
+	n := uint64(len(upstreams))
+
+	for range upstreams {
+		u := upstreams[(b.next.Add(1)-1)%n]
+		if u.Healthy() {
+			return u
+		}
+	}
+
+	return nil
+}
+
+type leastConnections struct{}
+
+// LeastConnections returns a balancer choosing the healthy upstream with the least active requests.
+func LeastConnections() Balancer {
+	return leastConnections{}
+}
+
+func (leastConnections) Pick(_ *http.Request, upstreams []*Upstream) *Upstream {
+	var picked *Upstream
+
+	for _, u := range upstreams {
+		if u.Healthy() && (picked == nil || u.Conns() < picked.Conns()) {
+			picked = u
+		}
+	}
+
+	return picked
+}
+
+// DefaultHashReplicas the default count of virtual nodes of each upstream in the hash ring.
+const DefaultHashReplicas = 64
+
+type hashNode struct {
+	hash     uint32
+	upstream *Upstream
+}
+
+type consistentHash struct {
+	keyFunc  func(req *http.Request) string
+	replicas int
+
+	once sync.Once
+	ring []hashNode
+}
+
+// ConsistentHash returns a balancer choosing upstreams by the consistent hash of the key of requests,
+// so that requests of the same key go to the same upstream while it's healthy.
+// The keyFunc returns the key of a request, vhttp.RemoteIP if nil.
+// The replicas is the count of virtual nodes of each upstream, DefaultHashReplicas if not positive.
+func ConsistentHash(keyFunc func(req *http.Request) string, replicas int) Balancer {
+	if keyFunc == nil {
+		keyFunc = vhttp.RemoteIP
+	}
+
+	if replicas <= 0 {
+		replicas = DefaultHashReplicas
+	}
+
+	return &consistentHash{
+		keyFunc:  keyFunc,
+		replicas: replicas,
+	}
+}
+
+func (b *consistentHash) Pick(req *http.Request, upstreams []*Upstream) *Upstream {
+	b.once.Do(func() {
+		for _, u := range upstreams {
+			for i := 0; i < b.replicas; i++ {
+				b.ring = append(b.ring, hashNode{
+					hash:     crc32.ChecksumIEEE([]byte(strconv.Itoa(i) + u.URL.String())),
+					upstream: u,
+				})
+			}
+		}
+
+		sort.Slice(b.ring, func(i, j int) bool {
+			return b.ring[i].hash < b.ring[j].hash
+		})
+	})
+
+	if len(b.ring) == 0 {
+		return nil
+	}
+
+	hash := crc32.ChecksumIEEE([]byte(b.keyFunc(req)))
+	start := sort.Search(len(b.ring), func(i int) bool {
+		return b.ring[i].hash >= hash
+	})
+
+	// walk the ring clockwise to the first healthy upstream.
+	for i := range b.ring {
+		node := b.ring[(start+i)%len(b.ring)]
+		if node.upstream.Healthy() {
+			return node.upstream
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,273 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package vhttpproxy builds reverse proxies balancing requests across upstreams.
+//
+//	p, err := vhttpproxy.NewBuilder("http://10.0.0.1:8080", "http://10.0.0.2:8080").
+//		Balancer(vhttpproxy.LeastConnections()).
+//		HealthCheck("/health", 5*time.Second).
+//		Build(runner)
+package vhttpproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/vogo/vogo/vlog"
+	"github.com/vogo/vogo/vnet/vhttp"
+	"github.com/vogo/vogo/vnet/vhttp/vhttperror"
+	"github.com/vogo/vogo/vnet/vhttp/vhttpresp"
+	"github.com/vogo/vogo/vsync/vrun"
+)
+
+const (
+	XForwardedHost  = "X-Forwarded-Host"
+	XForwardedProto = "X-Forwarded-Proto"
+
+	DefaultHealthCheckTimeout = 2 * time.Second
+)
+
+var (
+	ErrNoUpstream      = errors.New("no upstream")
+	ErrInvalidUpstream = errors.New("invalid upstream")
+)
+
+// Builder builds a reverse proxy.
+type Builder struct {
+	targets        []string
+	balancer       Balancer
+	transport      http.RoundTripper
+	modifyResponse func(*http.Response) error
+	healthPath     string
+	healthInterval time.Duration
+	healthTimeout  time.Duration
+}
+
+// NewBuilder create a builder of a reverse proxy to the target urls.
+func NewBuilder(targets ...string) *Builder {
+	return &Builder{
+		targets:       targets,
+		healthTimeout: DefaultHealthCheckTimeout,
+	}
+}
+
+// Balancer set the balancer, default RoundRobin.
+func (b *Builder) Balancer(balancer Balancer) *Builder {
+	b.balancer = balancer
+	return b
+}
+
+// Transport set the transport to send requests to upstreams, default http.DefaultTransport.
+func (b *Builder) Transport(transport http.RoundTripper) *Builder {
+	b.transport = transport
+	return b
+}
+
+// ModifyResponse set the func to modify responses of upstreams, see httputil.ReverseProxy.
+func (b *Builder) ModifyResponse(modifyResponse func(*http.Response) error) *Builder {
+	b.modifyResponse = modifyResponse
+	return b
+}
+
+// HealthCheck enable active health checks, which request the path of each upstream at the interval.
+// An upstream is healthy if the status of the response is less than 400.
+// The health checks are disabled if the interval is not positive.
+func (b *Builder) HealthCheck(path string, interval time.Duration) *Builder {
+	b.healthPath = path
+	b.healthInterval = interval
+
+	return b
+}
+
+// HealthCheckTimeout set the timeout of health check requests, default DefaultHealthCheckTimeout.
+func (b *Builder) HealthCheckTimeout(timeout time.Duration) *Builder {
+	b.healthTimeout = timeout
+	return b
+}
+
+// Build build the reverse proxy, the health checks stop when the runner is stopped.
+// A new runner is created if the runner is nil, which is stopped by Proxy.Stop.
+func (b *Builder) Build(runner *vrun.Runner) (*Proxy, error) {
+	if len(b.targets) == 0 {
+		return nil, ErrNoUpstream
+	}
+
+	// upstreams marked unhealthy passively are recovered only by the active health checks.
+	active := b.healthPath != "" && b.healthInterval > 0
+
+	p := &Proxy{
+		balancer: b.balancer,
+		passive:  active,
+	}
+
+	for _, target := range b.targets {
+		u, err := url.Parse(target)
+		if err != nil {
+			return nil, fmt.Errorf("%w: upstream %s", err, target)
+		}
+
+		if u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidUpstream, target)
+		}
+
+		p.upstreams = append(p.upstreams, &Upstream{URL: u})
+	}
+
+	if p.balancer == nil {
+		p.balancer = RoundRobin()
+	}
+
+	if runner == nil {
+		p.runner = vrun.New()
+	} else {
+		p.runner = runner.NewChild()
+	}
+
+	transport := b.transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	p.reverseProxy = &httputil.ReverseProxy{
+		Rewrite:        p.rewrite,
+		Transport:      transport,
+		ModifyResponse: b.modifyResponse,
+		ErrorHandler:   p.handleError,
+	}
+
+	if active {
+		client := &http.Client{Transport: transport, Timeout: b.healthTimeout}
+
+		for _, u := range p.upstreams {
+			p.runner.Interval(func() {
+				checkHealth(client, u, b.healthPath)
+			}, b.healthInterval)
+		}
+	}
+
+	return p, nil
+}
+
+type upstreamKey struct{}
+
+// Proxy a reverse proxy balancing requests across upstreams.
+type Proxy struct {
+	upstreams    []*Upstream
+	balancer     Balancer
+	reverseProxy *httputil.ReverseProxy
+	runner       *vrun.Runner
+
+	// passive marks an upstream unhealthy on connection errors, until the next health check passes.
+	passive bool
+}
+
+// Upstreams returns the upstreams of the proxy.
+func (p *Proxy) Upstreams() []*Upstream {
+	return p.upstreams
+}
+
+// Stop stop the health checks.
+func (p *Proxy) Stop() {
+	p.runner.Stop()
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	u := p.balancer.Pick(req, p.upstreams)
+	if u == nil {
+		vlog.Warnf("http proxy no healthy upstream | uri: %s | remote: %s", req.RequestURI, vhttp.RemoteIP(req))
+		vhttpresp.Error(w, req, vhttperror.ErrUnavailable)
+
+		return
+	}
+
+	u.conns.Add(1)
+	defer u.conns.Add(-1)
+
+	p.reverseProxy.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), upstreamKey{}, u)))
+}
+
+func (p *Proxy) rewrite(pr *httputil.ProxyRequest) {
+	u, _ := pr.In.Context().Value(upstreamKey{}).(*Upstream)
+
+	pr.SetURL(u.URL)
+	pr.Out.Host = pr.In.Host
+
+	setForwardedHeaders(pr.Out.Header, pr.In)
+}
+
+// setForwardedHeaders set X-Forwarded-For by appending the peer address to the inbound one,
+// and set X-Real-IP to the client ip the same as vhttp.RemoteIP.
+func setForwardedHeaders(header http.Header, in *http.Request) {
+	peer, _, err := net.SplitHostPort(in.RemoteAddr)
+	if err != nil {
+		peer = in.RemoteAddr
+	}
+
+	if prior := in.Header.Get(vhttp.XForwardedFor); prior != "" {
+		header.Set(vhttp.XForwardedFor, prior+", "+peer)
+	} else {
+		header.Set(vhttp.XForwardedFor, peer)
+	}
+
+	// vhttp.RemoteIP returns the whole X-Forwarded-For, of which the first one is the client.
+	realIP, _, _ := strings.Cut(vhttp.RemoteIP(in), ",")
+	header.Set(vhttp.XRealIP, strings.TrimSpace(realIP))
+
+	header.Set(XForwardedHost, in.Host)
+
+	if in.TLS != nil {
+		header.Set(XForwardedProto, "https")
+	} else {
+		header.Set(XForwardedProto, "http")
+	}
+}
+
+func (p *Proxy) handleError(w http.ResponseWriter, req *http.Request, err error) {
+	u, _ := req.Context().Value(upstreamKey{}).(*Upstream)
+
+	vlog.Errorf("http proxy upstream error | uri: %s | upstream: %s | remote: %s | err: %v",
+		req.RequestURI, u.URL, vhttp.RemoteIP(req), err)
+
+	if p.passive && vhttp.IsConnectionError(err) {
+		u.unhealthy.Store(true)
+	}
+
+	vhttpresp.Error(w, req, vhttperror.ErrBadGateway)
+}
+
+func checkHealth(client *http.Client, u *Upstream, path string) {
+	healthy := false
+
+	resp, err := client.Get(u.URL.JoinPath(path).String())
+	if err == nil {
+		_ = resp.Body.Close()
+		healthy = resp.StatusCode < http.StatusBadRequest
+	}
+
+	if was := u.Healthy(); was != healthy {
+		vlog.Infof("http proxy upstream health changed | upstream: %s | healthy: %t | err: %v", u.URL, healthy, err)
+	}
+
+	u.unhealthy.Store(!healthy)
+}
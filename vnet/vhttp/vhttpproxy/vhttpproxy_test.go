@@ -0,0 +1,213 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vhttpproxy_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/vnet/vhttp"
+	"github.com/vogo/vogo/vnet/vhttp/vhttpproxy"
+	"github.com/vogo/vogo/vsync/vrun"
+)
+
+func newBackend(name string, healthy *atomic.Bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/health" {
+			if !healthy.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+
+			return
+		}
+
+		w.Header().Set("X-Backend", name)
+		w.Header().Set("X-Got-Forwarded-For", req.Header.Get(vhttp.XForwardedFor))
+		w.Header().Set("X-Got-Real-IP", req.Header.Get(vhttp.XRealIP))
+	}))
+}
+
+func serve(p http.Handler, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api", nil)
+	req.RemoteAddr = "10.0.0.9:1234"
+
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestProxy(t *testing.T) {
+	t.Parallel()
+
+	var healthyA, healthyB atomic.Bool
+
+	healthyA.Store(true)
+	healthyB.Store(true)
+
+	a := newBackend("a", &healthyA)
+	defer a.Close()
+
+	b := newBackend("b", &healthyB)
+	defer b.Close()
+
+	runner := vrun.New()
+	defer runner.Stop()
+
+	p, err := vhttpproxy.NewBuilder(a.URL, b.URL).
+		HealthCheck("/health", 10*time.Millisecond).
+		Build(runner)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "a", serve(p, nil).Header().Get("X-Backend"))
+	assert.Equal(t, "b", serve(p, nil).Header().Get("X-Backend"))
+
+	w := serve(p, map[string]string{vhttp.XForwardedFor: "1.1.1.1, 2.2.2.2"})
+	assert.Equal(t, "1.1.1.1, 2.2.2.2, 10.0.0.9", w.Header().Get("X-Got-Forwarded-For"))
+	assert.Equal(t, "1.1.1.1", w.Header().Get("X-Got-Real-IP"))
+
+	w = serve(p, nil)
+	assert.Equal(t, "10.0.0.9", w.Header().Get("X-Got-Forwarded-For"))
+	assert.Equal(t, "10.0.0.9", w.Header().Get("X-Got-Real-IP"))
+
+	healthyA.Store(false)
+	time.Sleep(50 * time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, "b", serve(p, nil).Header().Get("X-Backend"))
+	}
+
+	healthyB.Store(false)
+	time.Sleep(50 * time.Millisecond)
+
+	w = serve(p, nil)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, `{"code":12,"msg":"service unavailable"}`, w.Body.String())
+}
+
+func TestProxyError(t *testing.T) {
+	t.Parallel()
+
+	var healthy atomic.Bool
+
+	a := newBackend("a", &healthy)
+	a.Close()
+
+	p, err := vhttpproxy.NewBuilder(a.URL).Build(nil)
+	assert.NoError(t, err)
+
+	defer p.Stop()
+
+	w := serve(p, nil)
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Equal(t, `{"code":11,"msg":"bad gateway"}`, w.Body.String())
+
+	_, err = vhttpproxy.NewBuilder("localhost").Build(nil)
+	assert.ErrorIs(t, err, vhttpproxy.ErrInvalidUpstream)
+}
+
+func TestBalancers(t *testing.T) {
+	t.Parallel()
+
+	p, err := vhttpproxy.NewBuilder("http://a", "http://b", "http://c", "http://d").Build(nil)
+	assert.NoError(t, err)
+
+	p.Stop()
+
+	upstreams := p.Upstreams()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	hash := vhttpproxy.ConsistentHash(func(req *http.Request) string {
+		return req.Header.Get("X-User")
+	}, 0)
+
+	for _, user := range []string{"u1", "u2", "u3", "u4", "u5"} {
+		req.Header.Set("X-User", user)
+		picked := hash.Pick(req, upstreams)
+
+		// the same key always goes to the same upstream.
+		assert.NotNil(t, picked)
+		assert.Same(t, picked, hash.Pick(req, upstreams))
+	}
+
+	// the remote ip is the key by default.
+	byIP := vhttpproxy.ConsistentHash(nil, 0)
+	expected := vhttpproxy.ConsistentHash(vhttp.RemoteIP, 0)
+
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5"} {
+		req.Header.Set(vhttp.XRealIP, ip)
+		assert.Same(t, expected.Pick(req, upstreams), byIP.Pick(req, upstreams))
+	}
+
+	leastConn := vhttpproxy.LeastConnections()
+	assert.Same(t, upstreams[0], leastConn.Pick(req, upstreams))
+}
+
+// flakyTransport fails the first proxied request with a connection error.
+type flakyTransport struct {
+	failed atomic.Bool
+}
+
+func (f *flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Path != "/health" && f.failed.CompareAndSwap(false, true) {
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	}
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestProxyRecover(t *testing.T) {
+	t.Parallel()
+
+	var healthy atomic.Bool
+
+	healthy.Store(true)
+
+	a := newBackend("a", &healthy)
+	defer a.Close()
+
+	for _, interval := range []time.Duration{0, 10 * time.Millisecond} {
+		p, err := vhttpproxy.NewBuilder(a.URL).
+			Transport(&flakyTransport{}).
+			HealthCheck("/health", interval).
+			Build(nil)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadGateway, serve(p, nil).Code)
+
+		// recovered by the health checks, or never marked unhealthy without them.
+		assert.Eventually(t, func() bool {
+			return p.Upstreams()[0].Healthy()
+		}, time.Second, 5*time.Millisecond)
+
+		assert.Equal(t, "a", serve(p, nil).Header().Get("X-Backend"))
+
+		p.Stop()
+	}
+}
@@ -0,0 +1,100 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vhttprate
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/vogo/vogo/vnet/vhttp"
+	"github.com/vogo/vogo/vnet/vhttp/vhttperror"
+	"github.com/vogo/vogo/vnet/vhttp/vhttpresp"
+	"github.com/vogo/vogo/vtime/vrate"
+)
+
+const HeaderRetryAfter = "Retry-After"
+
+// KeyFunc extracts the key to limit from a request, e.g. a user id.
+type KeyFunc func(req *http.Request) string
+
+// Middleware returns a middleware which rejects the requests exceeding the limit of their keys
+// with ErrTooManyRequests and a Retry-After header.
+// The remote ip is used as the key if keyFunc is nil.
+func Middleware(limiter *vrate.KeyedLimiter[string], keyFunc KeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return Handler(next, limiter, keyFunc)
+	}
+}
+
+// Handler wraps the handler to reject the requests exceeding the limit of their keys, see Middleware.
+func Handler(next http.Handler, limiter *vrate.KeyedLimiter[string], keyFunc KeyFunc) http.Handler {
+	if keyFunc == nil {
+		keyFunc = vhttp.RemoteIP
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ok, retryAfter := limiter.Reserve(keyFunc(req), time.Now())
+		if !ok {
+			w.Header().Set(HeaderRetryAfter, retryAfterSeconds(retryAfter))
+			vhttpresp.Error(w, req, vhttperror.ErrTooManyRequests)
+
+			return
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
+
+// retryAfterSeconds format the duration as delay seconds, rounded up.
+func retryAfterSeconds(d time.Duration) string {
+	seconds := int64((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	return strconv.FormatInt(seconds, 10)
+}
+
+type transport struct {
+	next    http.RoundTripper
+	limiter *vrate.KeyedLimiter[string]
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context(), req.URL.Host); err != nil {
+		// a round tripper must always close the body.
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+
+		return nil, err
+	}
+
+	return t.next.RoundTrip(req)
+}
+
+// Transport returns a round tripper which waits for the limit of the target host before sending a request.
+// It can be installed into the vhttp client by vhttp.WrapTransport.
+func Transport(next http.RoundTripper, limiter *vrate.KeyedLimiter[string]) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &transport{next: next, limiter: limiter}
+}
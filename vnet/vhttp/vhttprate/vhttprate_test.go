@@ -0,0 +1,99 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vhttprate_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/vnet/vhttp/vhttprate"
+	"github.com/vogo/vogo/vnet/vhttp/vhttpresp"
+	"github.com/vogo/vogo/vtime/vrate"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	limiter := vrate.NewKeyedLimiter[string](func() vrate.Limiter {
+		return vrate.NewTokenBucket(0.5, 1)
+	}, time.Minute)
+
+	handler := vhttprate.Middleware(limiter, nil)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		vhttpresp.OK(w, req)
+	}))
+
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, request("10.0.0.1:1234").Code)
+
+	w := request("10.0.0.1:1235")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get(vhttprate.HeaderRetryAfter))
+	assert.Equal(t, `{"code":104,"msg":"too many requests"}`, w.Body.String())
+
+	assert.Equal(t, http.StatusOK, request("10.0.0.2:1234").Code)
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestTransportClosesBody(t *testing.T) {
+	t.Parallel()
+
+	limiter := vrate.NewKeyedLimiter[string](func() vrate.Limiter {
+		return vrate.NewTokenBucket(0.001, 1)
+	}, time.Minute)
+
+	rt := vhttprate.Transport(http.DefaultTransport, limiter)
+
+	// consume the only token.
+	assert.True(t, limiter.Allow("example.com"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	body := &closeRecorder{Reader: strings.NewReader("data")}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://example.com/", body)
+	assert.NoError(t, err)
+
+	_, err = rt.RoundTrip(req)
+	assert.Error(t, err)
+	assert.True(t, body.closed)
+}
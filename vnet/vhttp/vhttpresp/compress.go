@@ -0,0 +1,136 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vhttpresp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+)
+
+// CompressMinSize responses smaller than this size are written without compression.
+var CompressMinSize = 1024
+
+var (
+	gzipWriterPool = sync.Pool{New: func() any {
+		return gzip.NewWriter(io.Discard)
+	}}
+	zlibWriterPool = sync.Pool{New: func() any {
+		return zlib.NewWriter(io.Discard)
+	}}
+)
+
+// negotiateEncoding choose the content encoding according to the Accept-Encoding header.
+// gzip is preferred over deflate when both have the same quality.
+// An empty string is returned if no compression is acceptable.
+func negotiateEncoding(acceptEncoding string) string {
+	var (
+		gzipQ, deflateQ, anyQ float64 = -1, -1, -1
+	)
+
+	for part := range strings.SplitSeq(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		q := parseQuality(params)
+
+		switch strings.ToLower(strings.TrimSpace(coding)) {
+		case EncodingGzip, "x-gzip":
+			gzipQ = q
+		case EncodingDeflate:
+			deflateQ = q
+		case "*":
+			anyQ = q
+		}
+	}
+
+	if gzipQ < 0 {
+		gzipQ = anyQ
+	}
+
+	if deflateQ < 0 {
+		deflateQ = anyQ
+	}
+
+	switch {
+	case gzipQ > 0 && gzipQ >= deflateQ:
+		return EncodingGzip
+	case deflateQ > 0:
+		return EncodingDeflate
+	default:
+		return ""
+	}
+}
+
+// compress data with the given content encoding.
+func compress(encoding string, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch encoding {
+	case EncodingGzip:
+		zw, _ := gzipWriterPool.Get().(*gzip.Writer)
+		defer gzipWriterPool.Put(zw)
+
+		zw.Reset(&buf)
+
+		if _, err := zw.Write(data); err != nil {
+			return nil, err
+		}
+
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+	case EncodingDeflate:
+		zw, _ := zlibWriterPool.Get().(*zlib.Writer)
+		defer zlibWriterPool.Put(zw)
+
+		zw.Reset(&buf)
+
+		if _, err := zw.Write(data); err != nil {
+			return nil, err
+		}
+
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return data, nil
+	}
+
+	return buf.Bytes(), nil
+}
+
+// addVary add a value to the Vary header if not exists.
+func addVary(header http.Header, value string) {
+	for _, v := range header.Values("Vary") {
+		for item := range strings.SplitSeq(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(item), value) {
+				return
+			}
+		}
+	}
+
+	header.Add("Vary", value)
+}
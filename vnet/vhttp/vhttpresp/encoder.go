@@ -0,0 +1,209 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vhttpresp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	MediaTypeJSON = "application/json"
+	MediaTypeText = "text/plain"
+)
+
+// Encoder encodes a response body into a specific content type.
+type Encoder interface {
+	// ContentType returns the value of the Content-Type header.
+	ContentType() string
+
+	// Encode writes the encoded response body to w.
+	Encode(w io.Writer, body *ResponseBody[any]) error
+}
+
+type encoder struct {
+	contentType string
+	encode      func(w io.Writer, body *ResponseBody[any]) error
+}
+
+func (e *encoder) ContentType() string {
+	return e.contentType
+}
+
+func (e *encoder) Encode(w io.Writer, body *ResponseBody[any]) error {
+	return e.encode(w, body)
+}
+
+// NewEncoder create an encoder from a content type and an encode function.
+func NewEncoder(contentType string, encode func(w io.Writer, body *ResponseBody[any]) error) Encoder {
+	return &encoder{contentType: contentType, encode: encode}
+}
+
+// JSONEncoder the default encoder.
+var JSONEncoder = NewEncoder("application/json; charset=utf-8", func(w io.Writer, body *ResponseBody[any]) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+
+	return err
+})
+
+// TextEncoder writes the data of the response as plain text, or the message if there is no data.
+var TextEncoder = NewEncoder("text/plain; charset=utf-8", func(w io.Writer, body *ResponseBody[any]) error {
+	var err error
+
+	switch v := body.Data.(type) {
+	case nil:
+		_, err = io.WriteString(w, body.Msg)
+	case string:
+		_, err = io.WriteString(w, v)
+	case []byte:
+		_, err = w.Write(v)
+	default:
+		_, err = fmt.Fprint(w, v)
+	}
+
+	return err
+})
+
+type mediaEncoder struct {
+	mediaType string
+	encoder   Encoder
+}
+
+var (
+	encodersLock sync.RWMutex
+
+	// encoders in the order of preference, the first one is the default.
+	encoders = []mediaEncoder{
+		{mediaType: MediaTypeJSON, encoder: JSONEncoder},
+		{mediaType: MediaTypeText, encoder: TextEncoder},
+	}
+)
+
+// RegisterEncoder register an encoder for the media type, e.g. "application/xml" or "text/csv".
+// An encoder registered for an exists media type replaces the old one.
+func RegisterEncoder(mediaType string, enc Encoder) {
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+	encodersLock.Lock()
+	defer encodersLock.Unlock()
+
+	for i := range encoders {
+		if encoders[i].mediaType == mediaType {
+			encoders[i].encoder = enc
+			return
+		}
+	}
+
+	encoders = append(encoders, mediaEncoder{mediaType: mediaType, encoder: enc})
+}
+
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// parseAccept parse the ranges of an Accept header, sorted by quality desc.
+// Ranges with zero quality are dropped.
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+
+	for part := range strings.SplitSeq(accept, ",") {
+		mediaType, params, _ := strings.Cut(part, ";")
+
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		if mediaType == "" {
+			continue
+		}
+
+		q := parseQuality(params)
+		if q <= 0 {
+			continue
+		}
+
+		ranges = append(ranges, acceptRange{mediaType: mediaType, q: q})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].q != ranges[j].q {
+			return ranges[i].q > ranges[j].q
+		}
+
+		// the more specific range takes precedence.
+		return strings.Count(ranges[i].mediaType, "*") < strings.Count(ranges[j].mediaType, "*")
+	})
+
+	return ranges
+}
+
+// parseQuality parse the q parameter from the parameters of an accept range, default 1.
+func parseQuality(params string) float64 {
+	for param := range strings.SplitSeq(params, ";") {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok || strings.TrimSpace(key) != "q" {
+			continue
+		}
+
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return 0
+		}
+
+		return q
+	}
+
+	return 1
+}
+
+func matchMediaType(pattern, mediaType string) bool {
+	if pattern == "*/*" || pattern == mediaType {
+		return true
+	}
+
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.HasPrefix(mediaType, prefix+"/")
+	}
+
+	return false
+}
+
+// negotiateEncoder choose the encoder according to the Accept header.
+// The default encoder is returned if nothing matches.
+func negotiateEncoder(accept string) Encoder {
+	encodersLock.RLock()
+	defer encodersLock.RUnlock()
+
+	for _, r := range parseAccept(accept) {
+		for _, e := range encoders {
+			if matchMediaType(r.mediaType, e.mediaType) {
+				return e.encoder
+			}
+		}
+	}
+
+	return encoders[0].encoder
+}
@@ -18,7 +18,7 @@
 package vhttpresp
 
 import (
-	"encoding/json"
+	"bytes"
 	"io"
 	"net/http"
 	"strings"
@@ -59,8 +59,10 @@ func CodeError(w http.ResponseWriter, req *http.Request, code int, err error) {
 }
 
 func Error(w http.ResponseWriter, req *http.Request, err error) {
+	status := 0
+
 	if c, ok := err.(vhttperror.StatusState); ok {
-		w.WriteHeader(c.Status())
+		status = c.Status()
 	}
 
 	code := vhttperror.CodeUnknownErr
@@ -69,7 +71,7 @@ func Error(w http.ResponseWriter, req *http.Request, err error) {
 		code = c.Code()
 	}
 
-	CodeMsg(w, req, code, err.Error())
+	write(w, req, status, code, err.Error(), nil)
 }
 
 func BadMsg(w http.ResponseWriter, req *http.Request, msg string) {
@@ -84,21 +86,37 @@ func CodeMsg(w http.ResponseWriter, req *http.Request, code int, msg string) {
 	Write(w, req, code, msg, nil)
 }
 
+// Write write the response body, which is encoded according to the Accept header of the request,
+// and compressed according to the Accept-Encoding header if its size reaches CompressMinSize.
 func Write(w http.ResponseWriter, req *http.Request, code int, msg string, data any) {
+	write(w, req, 0, code, msg, data)
+}
+
+// write the response body, the status code is written only if it's positive.
+func write(w http.ResponseWriter, req *http.Request, status, code int, msg string, data any) {
 	resp := ResponseBody[any]{
 		Code: code,
 		Msg:  msg,
 		Data: data,
 	}
-	b, err := json.Marshal(resp)
-	if err != nil {
-		vlog.Errorf("http response json marshal error | remote: %s | user_agent: %s | data: %s | err: %+v",
-			vhttp.RemoteIP(req), req.UserAgent(), b, err)
 
+	encoder := negotiateEncoder(req.Header.Get("Accept"))
+
+	var buf bytes.Buffer
+	if err := encoder.Encode(&buf, &resp); err != nil {
+		vlog.Errorf("http response encode error | remote: %s | user_agent: %s | content_type: %s | err: %+v",
+			vhttp.RemoteIP(req), req.UserAgent(), encoder.ContentType(), err)
+
+		w.Header().Set("Content-Type", JSONEncoder.ContentType())
+		if status > 0 {
+			w.WriteHeader(status)
+		}
 		_, _ = w.Write([]byte(`{"code":10,"msg":"internal error"}`))
 		return
 	}
 
+	b := buf.Bytes()
+
 	debugLog := strings.Contains(req.Header.Get("x-develop-flag"), "debug-log")
 
 	// log request
@@ -123,10 +141,31 @@ func Write(w http.ResponseWriter, req *http.Request, code int, msg string, data
 			req.RequestURI, vhttp.RemoteIP(req), req.UserAgent(), b)
 	}
 
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	_, err = w.Write(b)
+	header := w.Header()
+	header.Set("Content-Type", encoder.ContentType())
+	addVary(header, "Accept")
+	addVary(header, "Accept-Encoding")
+
+	if len(b) >= CompressMinSize {
+		if encoding := negotiateEncoding(req.Header.Get("Accept-Encoding")); encoding != "" {
+			compressed, err := compress(encoding, b)
+			if err != nil {
+				vlog.Errorf("http response compress error | remote: %s | encoding: %s | err: %+v",
+					vhttp.RemoteIP(req), encoding, err)
+			} else {
+				header.Set("Content-Encoding", encoding)
+				b = compressed
+			}
+		}
+	}
+
+	if status > 0 {
+		w.WriteHeader(status)
+	}
+
+	_, err := w.Write(b)
 	if err != nil {
 		vlog.Errorf("http response write error | remote: %s | user_agent: %s | data: %s | err: %+v",
-			vhttp.RemoteIP(req), req.UserAgent(), b, err)
+			vhttp.RemoteIP(req), req.UserAgent(), buf.Bytes(), err)
 	}
 }
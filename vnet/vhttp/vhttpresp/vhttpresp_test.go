@@ -0,0 +1,126 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vhttpresp_test
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/vnet/vhttp/vhttperror"
+	"github.com/vogo/vogo/vnet/vhttp/vhttpresp"
+)
+
+func doRequest(header map[string]string, handle func(w http.ResponseWriter, req *http.Request)) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	w := httptest.NewRecorder()
+	handle(w, req)
+
+	return w
+}
+
+func TestNegotiateContentType(t *testing.T) {
+	t.Parallel()
+
+	success := func(w http.ResponseWriter, req *http.Request) {
+		vhttpresp.Success(w, req, "hello")
+	}
+
+	w := doRequest(nil, success)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"code":0,"data":"hello"}`, w.Body.String())
+	assert.Equal(t, []string{"Accept", "Accept-Encoding"}, w.Header().Values("Vary"))
+
+	w = doRequest(map[string]string{"Accept": "text/plain"}, success)
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "hello", w.Body.String())
+
+	w = doRequest(map[string]string{"Accept": "text/html;q=0.9, text/*;q=0.5, */*;q=0.1"}, success)
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+
+	w = doRequest(map[string]string{"Accept": "image/png"}, success)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+
+	vhttpresp.RegisterEncoder("application/xml", vhttpresp.NewEncoder("application/xml; charset=utf-8",
+		func(w io.Writer, body *vhttpresp.ResponseBody[any]) error {
+			return xml.NewEncoder(w).Encode(body)
+		}))
+
+	w = doRequest(map[string]string{"Accept": "application/json;q=0.5, application/xml"}, success)
+	assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "<ResponseBody><Code>0</Code><Msg></Msg><Data>hello</Data></ResponseBody>", w.Body.String())
+}
+
+func TestCompress(t *testing.T) {
+	t.Parallel()
+
+	large := strings.Repeat("a", vhttpresp.CompressMinSize)
+
+	w := doRequest(map[string]string{"Accept-Encoding": "gzip, deflate"}, func(w http.ResponseWriter, req *http.Request) {
+		vhttpresp.Success(w, req, large)
+	})
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+
+	gr, err := gzip.NewReader(w.Body)
+	assert.NoError(t, err)
+
+	b, err := io.ReadAll(gr)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"code":0,"data":"`+large+`"}`, string(b))
+
+	w = doRequest(map[string]string{"Accept-Encoding": "gzip;q=0, deflate"}, func(w http.ResponseWriter, req *http.Request) {
+		vhttpresp.Success(w, req, large)
+	})
+	assert.Equal(t, "deflate", w.Header().Get("Content-Encoding"))
+
+	zr, err := zlib.NewReader(w.Body)
+	assert.NoError(t, err)
+
+	b, err = io.ReadAll(zr)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"code":0,"data":"`+large+`"}`, string(b))
+
+	// skip compression for small payloads
+	w = doRequest(map[string]string{"Accept-Encoding": "gzip"}, func(w http.ResponseWriter, req *http.Request) {
+		vhttpresp.Success(w, req, "small")
+	})
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, `{"code":0,"data":"small"}`, w.Body.String())
+}
+
+func TestErrorStatus(t *testing.T) {
+	t.Parallel()
+
+	w := doRequest(nil, func(w http.ResponseWriter, req *http.Request) {
+		vhttpresp.Error(w, req, vhttperror.ErrNotFound)
+	})
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"code":101,"msg":"not found"}`, w.Body.String())
+}
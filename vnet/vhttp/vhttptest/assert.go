@@ -0,0 +1,74 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vhttptest
+
+// TestingT the subset of testing.TB used by the assertions.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// AssertCalled asserts the count of requests to the method and the path.
+func (s *Server) AssertCalled(t TestingT, method, path string, times int) bool {
+	t.Helper()
+
+	if count := len(s.RequestsTo(method, path)); count != times {
+		t.Errorf("expected %d requests to %s %s, but got %d", times, method, path, count)
+		return false
+	}
+
+	return true
+}
+
+// AssertNotCalled asserts no request to the method and the path.
+func (s *Server) AssertNotCalled(t TestingT, method, path string) bool {
+	t.Helper()
+
+	return s.AssertCalled(t, method, path, 0)
+}
+
+// AssertExpectations asserts every route limited by Times has been called the times,
+// and every other route has been called at least once.
+func (s *Server) AssertExpectations(t TestingT) bool {
+	t.Helper()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ok := true
+
+	for _, r := range s.routes {
+		switch {
+		case r.times > 0 && r.calls != r.times:
+			t.Errorf("expected %d requests to route %s %s, but got %d", r.times, r.method, r.path, r.calls)
+			ok = false
+		case r.times == 0 && r.calls == 0:
+			t.Errorf("expected requests to route %s %s, but got none", r.method, r.path)
+			ok = false
+		}
+	}
+
+	for _, req := range s.requests {
+		if !req.Matched {
+			t.Errorf("unexpected request %s %s", req.Method, req.Path)
+			ok = false
+		}
+	}
+
+	return ok
+}
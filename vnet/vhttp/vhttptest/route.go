@@ -0,0 +1,259 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vhttptest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/vogo/vogo/vnet/vhttp"
+	"github.com/vogo/vogo/vnet/vhttp/vhttperror"
+	"github.com/vogo/vogo/vnet/vhttp/vhttpresp"
+)
+
+type failure int
+
+const (
+	failureNone failure = iota
+	failureReset
+	failurePartial
+)
+
+// Route the expectation of requests and the programmed reply.
+type Route struct {
+	server *Server
+	method string
+	path   string
+	times  int
+	calls  int
+
+	status  int
+	header  http.Header
+	body    []byte
+	content bool
+	handler http.HandlerFunc
+
+	delay        time.Duration
+	failure      failure
+	partialSize  int
+	dripSize     int
+	dripInterval time.Duration
+}
+
+// Times limit the route to match at most n requests, the following requests fall through to the next routes.
+func (r *Route) Times(n int) *Route {
+	r.times = n
+	return r
+}
+
+// Calls returns the count of requests matched the route.
+func (r *Route) Calls() int {
+	r.server.mu.Lock()
+	defer r.server.mu.Unlock()
+
+	return r.calls
+}
+
+// Header set a header of the reply.
+func (r *Route) Header(key, value string) *Route {
+	r.header.Set(key, value)
+	return r
+}
+
+// Reply reply the status and the body.
+func (r *Route) Reply(status int, body []byte) *Route {
+	r.status = status
+	r.body = body
+
+	return r
+}
+
+// ReplyString reply the status and the string body.
+func (r *Route) ReplyString(status int, body string) *Route {
+	return r.Reply(status, []byte(body))
+}
+
+// ReplyStatus reply the status with the status text as body, e.g. to inject 5xx failures.
+func (r *Route) ReplyStatus(status int) *Route {
+	return r.ReplyString(status, http.StatusText(status))
+}
+
+// ReplyJSON reply the status and the json body of v, it panics if v can't be marshaled.
+func (r *Route) ReplyJSON(status int, v any) *Route {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+
+	r.header.Set(vhttp.HeaderContentType, vhttp.ContentTypeJSON)
+
+	return r.Reply(status, b)
+}
+
+// ReplyData reply a successful vhttpresp.ResponseBody containing the data.
+func (r *Route) ReplyData(data any) *Route {
+	return r.ReplyJSON(http.StatusOK, vhttpresp.ResponseBody[any]{Code: vhttperror.CodeOK, Data: data})
+}
+
+// ReplyCode reply a vhttpresp.ResponseBody with the code and the message.
+func (r *Route) ReplyCode(code int, msg string) *Route {
+	return r.ReplyJSON(http.StatusOK, vhttpresp.ResponseBody[any]{Code: code, Msg: msg})
+}
+
+// ReplyContent reply the content by http.ServeContent, which supports range requests.
+func (r *Route) ReplyContent(content []byte) *Route {
+	r.content = true
+
+	return r.Reply(http.StatusOK, content)
+}
+
+// Handle reply by the handler.
+func (r *Route) Handle(handler http.HandlerFunc) *Route {
+	r.handler = handler
+	return r
+}
+
+// Delay delay the reply, it's interrupted if the client cancels the request.
+func (r *Route) Delay(d time.Duration) *Route {
+	r.delay = d
+	return r
+}
+
+// Reset reset the connection without replying.
+func (r *Route) Reset() *Route {
+	r.failure = failureReset
+	return r
+}
+
+// Partial reply the headers with the full Content-Length but only size bytes of the body,
+// and then close the connection.
+func (r *Route) Partial(size int) *Route {
+	r.failure = failurePartial
+	r.partialSize = size
+
+	return r
+}
+
+// Drip write the body slowly, size bytes each interval.
+func (r *Route) Drip(size int, interval time.Duration) *Route {
+	r.dripSize = size
+	r.dripInterval = interval
+
+	return r
+}
+
+func (r *Route) serve(w http.ResponseWriter, req *http.Request) {
+	if r.delay > 0 && !sleep(req, r.delay) {
+		return
+	}
+
+	if r.handler != nil {
+		r.handler(w, req)
+		return
+	}
+
+	if r.failure == failureReset {
+		resetConn(w)
+		return
+	}
+
+	for k, v := range r.header {
+		w.Header()[k] = v
+	}
+
+	if r.content && r.failure == failureNone && r.dripSize <= 0 {
+		http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(r.body))
+		return
+	}
+
+	body := r.body
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(r.status)
+
+	if r.failure == failurePartial && r.partialSize < len(body) {
+		body = body[:r.partialSize]
+	}
+
+	if r.dripSize > 0 {
+		for len(body) > 0 {
+			n := min(r.dripSize, len(body))
+			if _, err := w.Write(body[:n]); err != nil {
+				return
+			}
+
+			flush(w)
+
+			body = body[n:]
+
+			if len(body) > 0 && !sleep(req, r.dripInterval) {
+				return
+			}
+		}
+	} else {
+		_, _ = w.Write(body)
+	}
+
+	if r.failure == failurePartial {
+		flush(w)
+
+		// abort the connection after flushing the partial body.
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// sleep sleeps for the duration, returns false if the request is canceled.
+func sleep(req *http.Request, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-req.Context().Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func flush(w http.ResponseWriter) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// resetConn close the connection with a TCP RST.
+func resetConn(w http.ResponseWriter) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		panic(http.ErrAbortHandler)
+	}
+
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		_ = tcpConn.SetLinger(0)
+	}
+
+	_ = conn.Close()
+}
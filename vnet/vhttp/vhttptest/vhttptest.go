@@ -0,0 +1,174 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package vhttptest provides a programmable stub server for testing code which calls http services.
+//
+//	s := vhttptest.NewServer()
+//	defer s.Close()
+//
+//	s.On(http.MethodGet, "/users/1").ReplyData(user)
+//	s.On(http.MethodGet, "/files/a.txt").Times(1).Partial(10).Reply(http.StatusOK, content)
+//	s.On(http.MethodGet, "/files/a.txt").ReplyContent(content)
+package vhttptest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Request a request recorded by the server.
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+
+	// Matched whether the request matched a route.
+	Matched bool
+}
+
+// Server a stub http server, which replies requests according to the routes.
+// Requests matching no route are replied with 404.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	routes   []*Route
+	requests []Request
+}
+
+// NewServer create and start a stub server, which should be closed after using.
+func NewServer() *Server {
+	s := &Server{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// On add a route of the method and the path, an empty method matches any method,
+// and a path ending with "*" matches any path with the prefix.
+// Routes are matched in the order of adding.
+func (s *Server) On(method, path string) *Route {
+	r := &Route{
+		server: s,
+		method: method,
+		path:   path,
+		status: http.StatusOK,
+		header: make(http.Header),
+	}
+
+	s.mu.Lock()
+	s.routes = append(s.routes, r)
+	s.mu.Unlock()
+
+	return r
+}
+
+// URL returns the url of the path on the server.
+func (s *Server) URL(path string) string {
+	return s.Server.URL + path
+}
+
+// Requests returns all the recorded requests.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+// RequestsTo returns the recorded requests of the method and the path.
+func (s *Server) RequestsTo(method, path string) []Request {
+	var requests []Request
+
+	for _, req := range s.Requests() {
+		if req.Method == method && req.Path == path {
+			requests = append(requests, req)
+		}
+	}
+
+	return requests
+}
+
+// Reset remove all routes and recorded requests.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.routes = nil
+	s.requests = nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	recorded := Request{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.Query(),
+		Header: req.Header.Clone(),
+		Body:   body,
+	}
+
+	s.mu.Lock()
+
+	route := s.match(req)
+	if route != nil {
+		route.calls++
+		recorded.Matched = true
+	}
+
+	s.requests = append(s.requests, recorded)
+
+	s.mu.Unlock()
+
+	if route == nil {
+		http.NotFound(w, req)
+		return
+	}
+
+	route.serve(w, req)
+}
+
+func (s *Server) match(req *http.Request) *Route {
+	for _, r := range s.routes {
+		if r.method != "" && r.method != req.Method {
+			continue
+		}
+
+		if prefix, ok := strings.CutSuffix(r.path, "*"); ok {
+			if !strings.HasPrefix(req.URL.Path, prefix) {
+				continue
+			}
+		} else if r.path != req.URL.Path {
+			continue
+		}
+
+		if r.times > 0 && r.calls >= r.times {
+			continue
+		}
+
+		return r
+	}
+
+	return nil
+}
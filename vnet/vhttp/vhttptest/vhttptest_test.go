@@ -0,0 +1,111 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vhttptest_test
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/vbytes"
+	"github.com/vogo/vogo/vnet/vhttp"
+	"github.com/vogo/vogo/vnet/vhttp/vhttpresp"
+	"github.com/vogo/vogo/vnet/vhttp/vhttptest"
+)
+
+type user struct {
+	Name string `json:"name"`
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	s := vhttptest.NewServer()
+	defer s.Close()
+
+	s.On(http.MethodGet, "/users/1").Times(1).ReplyStatus(http.StatusServiceUnavailable)
+	s.On(http.MethodGet, "/users/1").ReplyData(user{Name: "a"})
+	s.On(http.MethodPost, "/users").ReplyCode(0, "ok")
+
+	var resp vhttpresp.ResponseBody[user]
+
+	assert.ErrorIs(t, vhttp.ParseGet(s.URL("/users/1"), nil, &resp), vhttp.ErrHTTPStatusNotOK)
+	assert.NoError(t, vhttp.ParseGet(s.URL("/users/1"), nil, &resp))
+	assert.Equal(t, "a", resp.Data.Name)
+
+	assert.NoError(t, vhttp.ParsePost(s.URL("/users"), nil, user{Name: "b"}, &resp))
+	assert.Equal(t, "ok", resp.Msg)
+
+	requests := s.RequestsTo(http.MethodPost, "/users")
+	assert.Len(t, requests, 1)
+	assert.Equal(t, `{"name":"b"}`, string(requests[0].Body))
+	assert.Equal(t, vhttp.ContentTypeJSON, requests[0].Header.Get(vhttp.HeaderContentType))
+
+	s.AssertCalled(t, http.MethodGet, "/users/1", 2)
+	s.AssertNotCalled(t, http.MethodDelete, "/users/1")
+	s.AssertExpectations(t)
+}
+
+func TestFailures(t *testing.T) {
+	t.Parallel()
+
+	s := vhttptest.NewServer()
+	defer s.Close()
+
+	content := []byte("0123456789")
+
+	s.On(http.MethodGet, "/reset").Reset()
+	s.On(http.MethodGet, "/partial").Partial(4).Reply(http.StatusOK, content)
+	s.On(http.MethodGet, "/drip").Drip(1, 20*time.Millisecond).Reply(http.StatusOK, content)
+	s.On(http.MethodGet, "/file").Delay(10 * time.Millisecond).ReplyContent(content)
+
+	dir := t.TempDir()
+
+	_, err := vhttp.Get(s.URL("/reset"))
+	assert.Error(t, err)
+
+	assert.ErrorIs(t, vhttp.DownloadFile(filepath.Join(dir, "partial"), s.URL("/partial"), time.Second),
+		io.ErrUnexpectedEOF)
+	assert.ErrorIs(t, vhttp.DownloadFile(filepath.Join(dir, "drip"), s.URL("/drip"), 30*time.Millisecond),
+		vbytes.ErrTimeout)
+
+	assert.NoError(t, vhttp.DownloadFile(filepath.Join(dir, "file"), s.URL("/file"), time.Second))
+
+	b, err := os.ReadFile(filepath.Join(dir, "file"))
+	assert.NoError(t, err)
+	assert.Equal(t, content, b)
+
+	// resume download by range request.
+	req, _ := http.NewRequest(http.MethodGet, s.URL("/file"), nil)
+	req.Header.Set("Range", "bytes=4-")
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	b, _ = io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	assert.Equal(t, "456789", string(b))
+}
@@ -0,0 +1,201 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vsync
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// SlowPolicy the policy applied when the buffer of a subscriber is full.
+type SlowPolicy int
+
+const (
+	// DropNewest drop the message being published.
+	DropNewest SlowPolicy = iota
+	// DropOldest drop the oldest message in the buffer.
+	DropOldest
+	// Block block the publisher until the message is buffered or the subscription is closed.
+	Block
+	// Disconnect close the subscription.
+	Disconnect
+)
+
+// Subscription a subscriber of a Broadcaster.
+type Subscription[T any] struct {
+	// C receives the messages, which is closed when the subscription is closed.
+	C <-chan T
+
+	c       chan T
+	b       *Broadcaster[T]
+	policy  SlowPolicy
+	done    chan struct{}
+	once    sync.Once
+	dropped atomic.Uint64
+}
+
+// Close close the subscription.
+func (s *Subscription[T]) Close() {
+	s.once.Do(func() {
+		// release the blocked publisher first.
+		close(s.done)
+
+		s.b.mu.Lock()
+		delete(s.b.subs, s)
+		s.b.mu.Unlock()
+
+		close(s.c)
+	})
+}
+
+// Dropped returns the count of the messages dropped for the subscriber.
+func (s *Subscription[T]) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// send returns false if the subscriber should be disconnected.
+func (s *Subscription[T]) send(v T) bool {
+	select {
+	case <-s.done:
+		return true
+	case s.c <- v:
+		return true
+	default:
+	}
+
+	switch s.policy {
+	case DropOldest:
+		for {
+			select {
+			case <-s.c:
+				s.dropped.Add(1)
+			default:
+			}
+
+			select {
+			case <-s.done:
+				return true
+			case s.c <- v:
+				return true
+			default:
+			}
+		}
+	case Block:
+		select {
+		case <-s.done:
+		case s.c <- v:
+		}
+
+		return true
+	case Disconnect:
+		s.dropped.Add(1)
+		return false
+	default:
+		s.dropped.Add(1)
+		return true
+	}
+}
+
+// Broadcaster fan out messages to the subscribers, the zero value is ready to use.
+type Broadcaster[T any] struct {
+	mu     sync.RWMutex
+	subs   map[*Subscription[T]]struct{}
+	closed bool
+}
+
+// NewBroadcaster create a new broadcaster.
+func NewBroadcaster[T any]() *Broadcaster[T] {
+	return &Broadcaster[T]{}
+}
+
+// Subscribe add a subscriber with a buffer of the size, and the policy applied when the buffer is full.
+// The subscription is closed immediately if the broadcaster is closed.
+// DropOldest of an unbuffered subscription is treated as DropNewest, as no message is buffered to drop.
+func (b *Broadcaster[T]) Subscribe(size int, policy SlowPolicy) *Subscription[T] {
+	if size < 1 && policy == DropOldest {
+		policy = DropNewest
+	}
+
+	c := make(chan T, size)
+
+	s := &Subscription[T]{
+		C:      c,
+		c:      c,
+		b:      b,
+		policy: policy,
+		done:   make(chan struct{}),
+	}
+
+	b.mu.Lock()
+
+	if b.closed {
+		b.mu.Unlock()
+		s.Close()
+
+		return s
+	}
+
+	if b.subs == nil {
+		b.subs = make(map[*Subscription[T]]struct{})
+	}
+
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+
+	return s
+}
+
+// Publish send the message to all subscribers.
+func (b *Broadcaster[T]) Publish(v T) {
+	var disconnected []*Subscription[T]
+
+	b.mu.RLock()
+
+	for s := range b.subs {
+		if !s.send(v) {
+			disconnected = append(disconnected, s)
+		}
+	}
+
+	b.mu.RUnlock()
+
+	for _, s := range disconnected {
+		s.Close()
+	}
+}
+
+// Len returns the count of the subscribers.
+func (b *Broadcaster[T]) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return len(b.subs)
+}
+
+// Close close the broadcaster and all subscriptions.
+func (b *Broadcaster[T]) Close() {
+	b.mu.Lock()
+	b.closed = true
+	subs := b.subs
+	b.subs = nil
+	b.mu.Unlock()
+
+	for s := range subs {
+		s.Close()
+	}
+}
@@ -0,0 +1,104 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vsync_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/vsync"
+)
+
+func TestBroadcaster(t *testing.T) {
+	t.Parallel()
+
+	b := vsync.NewBroadcaster[int]()
+
+	newest := b.Subscribe(2, vsync.DropNewest)
+	oldest := b.Subscribe(2, vsync.DropOldest)
+	disconnect := b.Subscribe(2, vsync.Disconnect)
+	block := b.Subscribe(0, vsync.Block)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		block.Close()
+	}()
+
+	for i := range 3 {
+		b.Publish(i)
+	}
+
+	assert.Equal(t, []int{0, 1}, drain(newest.C))
+	assert.Equal(t, uint64(1), newest.Dropped())
+	assert.Equal(t, []int{1, 2}, drain(oldest.C))
+	assert.Equal(t, uint64(1), oldest.Dropped())
+	assert.Equal(t, []int{0, 1}, drain(disconnect.C))
+	assert.Equal(t, 2, b.Len())
+
+	b.Close()
+	assert.Equal(t, 0, b.Len())
+
+	_, ok := <-newest.C
+	assert.False(t, ok)
+
+	_, ok = <-b.Subscribe(1, vsync.Block).C
+	assert.False(t, ok)
+}
+
+func TestBroadcasterUnbufferedDropOldest(t *testing.T) {
+	t.Parallel()
+
+	b := vsync.NewBroadcaster[int]()
+	defer b.Close()
+
+	sub := b.Subscribe(0, vsync.DropOldest)
+
+	published := make(chan struct{})
+
+	go func() {
+		b.Publish(1)
+		close(published)
+	}()
+
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("publish blocked by an unbuffered DropOldest subscription")
+	}
+
+	assert.Equal(t, uint64(1), sub.Dropped())
+}
+
+// drain receive the buffered messages.
+func drain(c <-chan int) []int {
+	var values []int
+
+	for {
+		select {
+		case v, ok := <-c:
+			if !ok {
+				return values
+			}
+
+			values = append(values, v)
+		default:
+			return values
+		}
+	}
+}
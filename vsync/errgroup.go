@@ -0,0 +1,111 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vsync
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Group run goroutines with a concurrency limit, and cancel the context on the first error.
+type Group struct {
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup
+	sem    chan struct{}
+
+	errOnce sync.Once
+	err     error
+}
+
+// NewGroup create a group and the context derived from ctx, which is canceled when any goroutine fails
+// or Wait returns. The limit is the max count of the running goroutines, no limit if it's not positive.
+func NewGroup(ctx context.Context, limit int) (*Group, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	g := &Group{cancel: cancel}
+
+	if limit > 0 {
+		g.sem = make(chan struct{}, limit)
+	}
+
+	return g, ctx
+}
+
+// Go run f in a goroutine, blocks until the count of running goroutines is under the limit.
+// A panic of f is recovered and returned as an error wrapping ErrPanic.
+func (g *Group) Go(f func() error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+
+	g.run(f)
+}
+
+// TryGo run f in a goroutine only if the count of running goroutines is under the limit,
+// returns whether f is started.
+func (g *Group) TryGo(f func() error) bool {
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		default:
+			return false
+		}
+	}
+
+	g.run(f)
+
+	return true
+}
+
+func (g *Group) run(f func() error) {
+	g.wg.Add(1)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				g.fail(fmt.Errorf("%w: %v", ErrPanic, r))
+			}
+
+			if g.sem != nil {
+				<-g.sem
+			}
+
+			g.wg.Done()
+		}()
+
+		if err := f(); err != nil {
+			g.fail(err)
+		}
+	}()
+}
+
+func (g *Group) fail(err error) {
+	g.errOnce.Do(func() {
+		g.err = err
+		g.cancel(err)
+	})
+}
+
+// Wait wait for all goroutines, and returns the first error.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel(nil)
+
+	return g.err
+}
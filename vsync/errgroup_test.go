@@ -0,0 +1,86 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vsync_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/vsync"
+)
+
+func TestGroup(t *testing.T) {
+	t.Parallel()
+
+	g, _ := vsync.NewGroup(context.Background(), 2)
+
+	var running, peak int64
+
+	for range 10 {
+		g.Go(func() error {
+			n := atomic.AddInt64(&running, 1)
+
+			for {
+				p := atomic.LoadInt64(&peak)
+				if n <= p || atomic.CompareAndSwapInt64(&peak, p, n) {
+					break
+				}
+			}
+
+			time.Sleep(time.Millisecond)
+			atomic.AddInt64(&running, -1)
+
+			return nil
+		})
+	}
+
+	assert.NoError(t, g.Wait())
+	assert.Equal(t, int64(2), atomic.LoadInt64(&peak))
+
+	errFirst := errors.New("first error")
+
+	g, ctx := vsync.NewGroup(context.Background(), 1)
+
+	release := make(chan struct{})
+
+	g.Go(func() error {
+		<-release
+		return errFirst
+	})
+
+	assert.False(t, g.TryGo(func() error { return nil }))
+	close(release)
+
+	g.Go(func() error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	assert.ErrorIs(t, g.Wait(), errFirst)
+	assert.ErrorIs(t, context.Cause(ctx), errFirst)
+
+	g, _ = vsync.NewGroup(context.Background(), 0)
+	g.Go(func() error {
+		panic("test panic")
+	})
+	assert.ErrorIs(t, g.Wait(), vsync.ErrPanic)
+}
@@ -0,0 +1,81 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vsync
+
+import (
+	"context"
+	"sync"
+)
+
+// Event a set-once event broadcasting to all waiters, the zero value is ready to use.
+type Event struct {
+	once sync.Once
+	init sync.Once
+	c    chan struct{}
+}
+
+// NewEvent create a new event.
+func NewEvent() *Event {
+	return &Event{}
+}
+
+func (e *Event) ch() chan struct{} {
+	e.init.Do(func() {
+		e.c = make(chan struct{})
+	})
+
+	return e.c
+}
+
+// Set set the event and wake up all waiters, returns whether it's set by this call.
+func (e *Event) Set() bool {
+	set := false
+
+	e.once.Do(func() {
+		close(e.ch())
+
+		set = true
+	})
+
+	return set
+}
+
+// IsSet returns whether the event is set.
+func (e *Event) IsSet() bool {
+	select {
+	case <-e.ch():
+		return true
+	default:
+		return false
+	}
+}
+
+// Done returns a channel closed when the event is set.
+func (e *Event) Done() <-chan struct{} {
+	return e.ch()
+}
+
+// Wait wait for the event until the context is done.
+func (e *Event) Wait(ctx context.Context) error {
+	select {
+	case <-e.ch():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,96 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vsync_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/vsync"
+)
+
+func TestEvent(t *testing.T) {
+	t.Parallel()
+
+	var (
+		e  vsync.Event
+		wg sync.WaitGroup
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, e.Wait(ctx), context.DeadlineExceeded)
+	assert.False(t, e.IsSet())
+
+	for range 3 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, e.Wait(context.Background()))
+		}()
+	}
+
+	assert.True(t, e.Set())
+	assert.False(t, e.Set())
+	wg.Wait()
+
+	assert.True(t, e.IsSet())
+	<-e.Done()
+}
+
+func TestOnceChan(t *testing.T) {
+	t.Parallel()
+
+	c := vsync.NewOnceChan[int](1)
+	assert.False(t, vsync.IsClosed(c.Done()))
+	assert.True(t, c.Send(1))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		c.Close()
+	}()
+
+	// blocked until closed.
+	assert.False(t, c.Send(2))
+	assert.False(t, c.Close())
+	assert.True(t, c.IsClosed())
+
+	assert.True(t, vsync.IsClosed(c.Done()))
+
+	// the buffered value is received before closed.
+	assert.Equal(t, 1, <-c.C)
+
+	_, ok := <-c.C
+	assert.False(t, ok)
+
+	done := make(chan struct{}, 1)
+	assert.False(t, vsync.IsChanClosed(done))
+	done <- struct{}{}
+	assert.True(t, vsync.IsChanClosed(done))
+	assert.True(t, vsync.IsChanClosed(nil))
+	assert.True(t, vsync.IsClosed(nil))
+
+	ch := make(chan string)
+	assert.True(t, vsync.SafeClose(ch))
+	assert.False(t, vsync.SafeClose(ch))
+}
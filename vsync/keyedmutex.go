@@ -0,0 +1,101 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vsync
+
+import "sync"
+
+type keyedLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// KeyedMutex lock per key, the lock of a key is removed once it's not used, the zero value is ready to use.
+type KeyedMutex[K comparable] struct {
+	mu    sync.Mutex
+	locks map[K]*keyedLock
+}
+
+// Lock lock the key.
+func (m *KeyedMutex[K]) Lock(key K) {
+	m.acquire(key).mu.Lock()
+}
+
+// TryLock try to lock the key, returns whether it's locked.
+func (m *KeyedMutex[K]) TryLock(key K) bool {
+	l := m.acquire(key)
+
+	if l.mu.TryLock() {
+		return true
+	}
+
+	m.release(key, l)
+
+	return false
+}
+
+// Unlock unlock the key, panics if the key is not locked.
+func (m *KeyedMutex[K]) Unlock(key K) {
+	m.mu.Lock()
+	l, ok := m.locks[key]
+	m.mu.Unlock()
+
+	if !ok {
+		panic("vsync: unlock of unlocked key")
+	}
+
+	l.mu.Unlock()
+	m.release(key, l)
+}
+
+// Len returns the count of the keys locked or waited.
+func (m *KeyedMutex[K]) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.locks)
+}
+
+func (m *KeyedMutex[K]) acquire(key K) *keyedLock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.locks == nil {
+		m.locks = make(map[K]*keyedLock)
+	}
+
+	l, ok := m.locks[key]
+	if !ok {
+		l = &keyedLock{}
+		m.locks[key] = l
+	}
+
+	l.refs++
+
+	return l
+}
+
+func (m *KeyedMutex[K]) release(key K, l *keyedLock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l.refs--
+
+	if l.refs == 0 {
+		delete(m.locks, key)
+	}
+}
@@ -0,0 +1,67 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vsync_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/vsync"
+)
+
+func TestKeyedMutex(t *testing.T) {
+	t.Parallel()
+
+	var (
+		m      vsync.KeyedMutex[string]
+		wg     sync.WaitGroup
+		a, b   int
+		counts = map[string]*int{"a": &a, "b": &b}
+	)
+
+	for i := range 100 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			key := []string{"a", "b"}[i%2]
+
+			m.Lock(key)
+			*counts[key]++
+			m.Unlock(key)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, 50, a)
+	assert.Equal(t, 50, b)
+	assert.Equal(t, 0, m.Len())
+
+	m.Lock("a")
+	assert.False(t, m.TryLock("a"))
+	assert.True(t, m.TryLock("b"))
+	assert.Equal(t, 2, m.Len())
+
+	m.Unlock("a")
+	m.Unlock("b")
+	assert.Equal(t, 0, m.Len())
+	assert.Panics(t, func() { m.Unlock("a") })
+}
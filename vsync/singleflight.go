@@ -0,0 +1,133 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vsync
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrPanic a goroutine panicked, the panic is recovered and returned as an error wrapping it.
+var ErrPanic = errors.New("goroutine panic")
+
+// Result the result of a Singleflight call.
+type Result[V any] struct {
+	Val    V
+	Err    error
+	Shared bool
+}
+
+type call[V any] struct {
+	wg    sync.WaitGroup
+	val   V
+	err   error
+	dups  int
+	chans []chan<- Result[V]
+}
+
+// Singleflight deduplicate concurrent calls of the same key, the zero value is ready to use.
+type Singleflight[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*call[V]
+}
+
+// Do call fn and returns its result, the concurrent callers of the same key wait for and share the result.
+// The shared is true if the result is given to multiple callers.
+// A panic of fn is recovered and returned as an error wrapping ErrPanic.
+func (g *Singleflight[K, V]) Do(key K, fn func() (V, error)) (v V, err error, shared bool) {
+	g.mu.Lock()
+
+	if g.calls == nil {
+		g.calls = make(map[K]*call[V])
+	}
+
+	if c, ok := g.calls[key]; ok {
+		c.dups++
+		g.mu.Unlock()
+		c.wg.Wait()
+
+		return c.val, c.err, true
+	}
+
+	c := &call[V]{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	g.doCall(c, key, fn)
+
+	return c.val, c.err, c.dups > 0
+}
+
+// DoChan is like Do but returns a channel receiving the result.
+func (g *Singleflight[K, V]) DoChan(key K, fn func() (V, error)) <-chan Result[V] {
+	ch := make(chan Result[V], 1)
+
+	g.mu.Lock()
+
+	if g.calls == nil {
+		g.calls = make(map[K]*call[V])
+	}
+
+	if c, ok := g.calls[key]; ok {
+		c.dups++
+		c.chans = append(c.chans, ch)
+		g.mu.Unlock()
+
+		return ch
+	}
+
+	c := &call[V]{chans: []chan<- Result[V]{ch}}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	go g.doCall(c, key, fn)
+
+	return ch
+}
+
+// Forget forget the key, the later calls of it don't wait for the running one.
+func (g *Singleflight[K, V]) Forget(key K) {
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+}
+
+func (g *Singleflight[K, V]) doCall(c *call[V], key K, fn func() (V, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.err = fmt.Errorf("%w: %v", ErrPanic, r)
+		}
+
+		g.mu.Lock()
+		c.wg.Done()
+
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+
+		for _, ch := range c.chans {
+			ch <- Result[V]{Val: c.val, Err: c.err, Shared: c.dups > 0}
+		}
+		g.mu.Unlock()
+	}()
+
+	c.val, c.err = fn()
+}
@@ -0,0 +1,305 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vbreaker
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultConsecutiveFailures = 5
+	DefaultWindow              = time.Minute
+	DefaultCooldown            = 30 * time.Second
+	DefaultHalfOpenRequests    = 1
+)
+
+var (
+	ErrOpen            = errors.New("circuit breaker is open")
+	ErrTooManyRequests = errors.New("too many requests in half-open state")
+)
+
+// State the state of a circuit breaker.
+type State int
+
+const (
+	// StateClosed requests are allowed, and failures are counted.
+	StateClosed State = iota
+	// StateOpen requests are rejected until the cooldown passes.
+	StateOpen
+	// StateHalfOpen limited requests are allowed to probe whether the dependency recovers.
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// Counts the counts of requests in the current window or state.
+type Counts struct {
+	Requests            int
+	Successes           int
+	Failures            int
+	ConsecutiveFailures int
+}
+
+// Config the config of a circuit breaker.
+// If neither ConsecutiveFailures nor FailureRatio is set, DefaultConsecutiveFailures is used.
+type Config struct {
+	// ConsecutiveFailures trips the breaker when the count of consecutive failures reaches it, 0 to disable.
+	ConsecutiveFailures int
+
+	// FailureRatio trips the breaker when the ratio of failures in the window reaches it, 0 to disable.
+	FailureRatio float64
+
+	// MinRequests the minimum count of requests in the window before FailureRatio applies.
+	MinRequests int
+
+	// Window the interval to clear the counts in closed state, default DefaultWindow.
+	Window time.Duration
+
+	// Cooldown the duration of open state before switching to half-open, default DefaultCooldown.
+	Cooldown time.Duration
+
+	// HalfOpenRequests the max count of requests allowed in half-open state, default DefaultHalfOpenRequests.
+	// The breaker closes when all of them succeed.
+	HalfOpenRequests int
+
+	// IsFailure classifies whether an error is a failure, default any non-nil error.
+	IsFailure func(err error) bool
+
+	// OnStateChange is called when the state changes, it must not call the breaker.
+	OnStateChange func(name string, from, to State)
+}
+
+// Breaker a circuit breaker.
+type Breaker struct {
+	name string
+	cfg  Config
+
+	mu          sync.Mutex
+	state       State
+	generation  uint64
+	counts      Counts
+	windowStart time.Time
+	openedAt    time.Time
+}
+
+// New create a circuit breaker.
+func New(name string, cfg Config) *Breaker {
+	if cfg.ConsecutiveFailures <= 0 && cfg.FailureRatio <= 0 {
+		cfg.ConsecutiveFailures = DefaultConsecutiveFailures
+	}
+
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultWindow
+	}
+
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = DefaultCooldown
+	}
+
+	if cfg.HalfOpenRequests <= 0 {
+		cfg.HalfOpenRequests = DefaultHalfOpenRequests
+	}
+
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = func(err error) bool {
+			return err != nil
+		}
+	}
+
+	return &Breaker{
+		name:        name,
+		cfg:         cfg,
+		windowStart: time.Now(),
+	}
+}
+
+// Name returns the name of the breaker.
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// State returns the current state.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.currentState(time.Now())
+}
+
+// Counts returns the counts of the current window or state.
+func (b *Breaker) Counts() Counts {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.currentState(time.Now())
+
+	return b.counts
+}
+
+// Allow checks whether a request is allowed, if so, the returned done func must be called
+// with the result of the request.
+func (b *Breaker) Allow() (done func(err error), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+
+	switch b.currentState(now) {
+	case StateOpen:
+		return nil, ErrOpen
+	case StateHalfOpen:
+		if b.counts.Requests >= b.cfg.HalfOpenRequests {
+			return nil, ErrTooManyRequests
+		}
+	case StateClosed:
+	}
+
+	b.counts.Requests++
+	generation := b.generation
+
+	return func(err error) {
+		b.done(generation, err)
+	}, nil
+}
+
+// Do calls fn if the breaker allows, a panic of fn is counted as a failure.
+func (b *Breaker) Do(fn func() error) error {
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			done(fmt.Errorf("panic: %v", r))
+			panic(r)
+		}
+	}()
+
+	err = fn()
+	done(err)
+
+	return err
+}
+
+// Execute calls fn if the breaker allows, see Breaker.Do.
+func Execute[T any](b *Breaker, fn func() (T, error)) (T, error) {
+	var result T
+
+	err := b.Do(func() error {
+		var err error
+		result, err = fn()
+
+		return err
+	})
+
+	return result, err
+}
+
+func (b *Breaker) done(generation uint64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	state := b.currentState(now)
+
+	// ignore the result of a request allowed in the previous generation.
+	if generation != b.generation {
+		return
+	}
+
+	if !b.cfg.IsFailure(err) {
+		b.counts.Successes++
+		b.counts.ConsecutiveFailures = 0
+
+		if state == StateHalfOpen && b.counts.Successes >= b.cfg.HalfOpenRequests {
+			b.setState(StateClosed, now)
+		}
+
+		return
+	}
+
+	b.counts.Failures++
+	b.counts.ConsecutiveFailures++
+
+	if state == StateHalfOpen || b.tripped() {
+		b.setState(StateOpen, now)
+	}
+}
+
+func (b *Breaker) tripped() bool {
+	if b.cfg.ConsecutiveFailures > 0 && b.counts.ConsecutiveFailures >= b.cfg.ConsecutiveFailures {
+		return true
+	}
+
+	return b.cfg.FailureRatio > 0 &&
+		b.counts.Requests >= b.cfg.MinRequests &&
+		float64(b.counts.Failures) >= b.cfg.FailureRatio*float64(b.counts.Requests)
+}
+
+// currentState update the state according to the time, and returns it.
+func (b *Breaker) currentState(now time.Time) State {
+	switch b.state {
+	case StateOpen:
+		if now.Sub(b.openedAt) >= b.cfg.Cooldown {
+			b.setState(StateHalfOpen, now)
+		}
+	case StateClosed:
+		if now.Sub(b.windowStart) >= b.cfg.Window {
+			b.newGeneration(now)
+		}
+	case StateHalfOpen:
+	}
+
+	return b.state
+}
+
+func (b *Breaker) setState(state State, now time.Time) {
+	from := b.state
+	b.state = state
+
+	if state == StateOpen {
+		b.openedAt = now
+	}
+
+	b.newGeneration(now)
+
+	if b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(b.name, from, state)
+	}
+}
+
+func (b *Breaker) newGeneration(now time.Time) {
+	b.generation++
+	b.counts = Counts{}
+	b.windowStart = now
+}
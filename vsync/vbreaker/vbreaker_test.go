@@ -0,0 +1,121 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vbreaker_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/vsync/vbreaker"
+)
+
+var errTest = errors.New("test error")
+
+func TestConsecutiveFailures(t *testing.T) {
+	t.Parallel()
+
+	var changes []string
+
+	b := vbreaker.New("test", vbreaker.Config{
+		ConsecutiveFailures: 2,
+		Cooldown:            20 * time.Millisecond,
+		OnStateChange: func(_ string, from, to vbreaker.State) {
+			changes = append(changes, from.String()+"->"+to.String())
+		},
+	})
+
+	fail := func() error { return errTest }
+	succeed := func() error { return nil }
+
+	assert.ErrorIs(t, b.Do(fail), errTest)
+	assert.NoError(t, b.Do(succeed))
+	assert.ErrorIs(t, b.Do(fail), errTest)
+	assert.Equal(t, vbreaker.StateClosed, b.State())
+	assert.ErrorIs(t, b.Do(fail), errTest)
+	assert.Equal(t, vbreaker.StateOpen, b.State())
+	assert.ErrorIs(t, b.Do(succeed), vbreaker.ErrOpen)
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, vbreaker.StateHalfOpen, b.State())
+
+	// failure in half-open state opens the breaker again.
+	assert.ErrorIs(t, b.Do(fail), errTest)
+	assert.Equal(t, vbreaker.StateOpen, b.State())
+
+	time.Sleep(30 * time.Millisecond)
+
+	v, err := vbreaker.Execute(b, func() (int, error) { return 1, nil })
+	assert.NoError(t, err)
+	assert.Equal(t, 1, v)
+	assert.Equal(t, vbreaker.StateClosed, b.State())
+
+	assert.Equal(t, []string{
+		"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed",
+	}, changes)
+}
+
+func TestFailureRatio(t *testing.T) {
+	t.Parallel()
+
+	b := vbreaker.New("test", vbreaker.Config{
+		FailureRatio:     0.5,
+		MinRequests:      4,
+		HalfOpenRequests: 2,
+		IsFailure: func(err error) bool {
+			return errors.Is(err, errTest)
+		},
+	})
+
+	fail := func() error { return errTest }
+	succeed := func() error { return nil }
+
+	assert.ErrorIs(t, b.Do(fail), errTest)
+	assert.ErrorIs(t, b.Do(fail), errTest)
+	assert.Equal(t, vbreaker.StateClosed, b.State())
+	assert.NoError(t, b.Do(succeed))
+	assert.Error(t, b.Do(func() error { return errors.New("not a failure") }))
+	assert.Equal(t, vbreaker.StateClosed, b.State())
+	assert.ErrorIs(t, b.Do(fail), errTest)
+	assert.Equal(t, vbreaker.StateOpen, b.State())
+	assert.Equal(t, vbreaker.Counts{}, b.Counts())
+}
+
+func TestHalfOpenRequests(t *testing.T) {
+	t.Parallel()
+
+	b := vbreaker.New("test", vbreaker.Config{
+		ConsecutiveFailures: 1,
+		Cooldown:            time.Millisecond,
+		HalfOpenRequests:    1,
+	})
+
+	assert.ErrorIs(t, b.Do(func() error { return errTest }), errTest)
+
+	time.Sleep(5 * time.Millisecond)
+
+	done, err := b.Allow()
+	assert.NoError(t, err)
+
+	_, err = b.Allow()
+	assert.ErrorIs(t, err, vbreaker.ErrTooManyRequests)
+
+	done(nil)
+	assert.Equal(t, vbreaker.StateClosed, b.State())
+}
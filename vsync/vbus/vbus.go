@@ -0,0 +1,278 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package vbus provides an in-process pub/sub bus with typed subscribers.
+//
+// Topics are dot separated, e.g. "order.created".
+// A pattern matches a topic segment by segment, where "*" matches exactly one segment,
+// and "**" matches zero or more segments, e.g. "order.*" and "**.created".
+//
+//	bus := vbus.New()
+//	sub, err := vbus.Subscribe(bus, "order.*", func(topic string, o *Order) { ... }, vbus.Async(64))
+//	bus.Publish("order.created", order)
+//	sub.Unsubscribe()
+package vbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/vogo/vogo/vlog"
+	"github.com/vogo/vogo/vsync"
+	"github.com/vogo/vogo/vsync/vrun"
+)
+
+var ErrInvalidPattern = errors.New("invalid topic pattern")
+
+const (
+	wildcardOne  = "*"
+	wildcardMany = "**"
+)
+
+type options struct {
+	async  bool
+	buffer int
+	runner *vrun.Runner
+}
+
+// Option the option of a subscription.
+type Option func(o *options)
+
+// Async deliver messages in a goroutine of the subscriber with a buffer of the size,
+// the publisher blocks if the buffer is full. Messages are delivered in the order they're published.
+// By default, messages are delivered synchronously in the goroutine of the publisher.
+func Async(buffer int) Option {
+	return func(o *options) {
+		o.async = true
+		o.buffer = max(buffer, 0)
+	}
+}
+
+// WithRunner unsubscribe when the runner is stopped.
+func WithRunner(runner *vrun.Runner) Option {
+	return func(o *options) {
+		o.runner = runner
+	}
+}
+
+type envelope struct {
+	topic string
+	msg   any
+}
+
+// Subscription a subscription of a bus, which is a handle to unsubscribe.
+type Subscription struct {
+	bus      *Bus
+	pattern  string
+	segments []string
+	deliver  func(topic string, msg any)
+
+	queue  *vsync.OnceChan[envelope]
+	closed atomic.Bool
+	stop   func() bool
+}
+
+// Pattern returns the topic pattern of the subscription.
+func (s *Subscription) Pattern() string {
+	return s.pattern
+}
+
+// Unsubscribe stop delivering messages to the subscriber, returns false if it's already unsubscribed.
+// The messages buffered by an async subscriber are still delivered.
+func (s *Subscription) Unsubscribe() bool {
+	if !s.closed.CompareAndSwap(false, true) {
+		return false
+	}
+
+	s.bus.remove(s)
+
+	if s.queue != nil {
+		s.queue.Close()
+	}
+
+	if s.stop != nil {
+		s.stop()
+	}
+
+	return true
+}
+
+func (s *Subscription) publish(topic string, msg any) {
+	if s.closed.Load() {
+		return
+	}
+
+	if s.queue != nil {
+		s.queue.Send(envelope{topic: topic, msg: msg})
+		return
+	}
+
+	s.safeDeliver(topic, msg)
+}
+
+func (s *Subscription) consume() {
+	for e := range s.queue.C {
+		s.safeDeliver(e.topic, e.msg)
+	}
+}
+
+func (s *Subscription) safeDeliver(topic string, msg any) {
+	defer func() {
+		if r := recover(); r != nil {
+			vlog.Errorf("bus subscriber panic | topic: %s | pattern: %s | panic: %v | stack: %s",
+				topic, s.pattern, r, debug.Stack())
+		}
+	}()
+
+	s.deliver(topic, msg)
+}
+
+// Bus an in-process pub/sub bus.
+type Bus struct {
+	mu   sync.RWMutex
+	subs []*Subscription
+}
+
+// New create a new bus.
+func New() *Bus {
+	return &Bus{}
+}
+
+// Subscribe subscribe the messages of type T published to the topics matching the pattern.
+// Messages of other types are ignored by the subscriber.
+func Subscribe[T any](b *Bus, pattern string, handler func(topic string, msg T), opts ...Option) (*Subscription, error) {
+	return b.subscribe(pattern, func(topic string, msg any) {
+		if m, ok := msg.(T); ok {
+			handler(topic, m)
+		}
+	}, opts)
+}
+
+func (b *Bus) subscribe(pattern string, deliver func(topic string, msg any), opts []Option) (*Subscription, error) {
+	segments := strings.Split(pattern, ".")
+
+	for _, segment := range segments {
+		if segment == "" || (segment != wildcardOne && segment != wildcardMany && strings.Contains(segment, "*")) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPattern, pattern)
+		}
+	}
+
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	s := &Subscription{
+		bus:      b,
+		pattern:  pattern,
+		segments: segments,
+		deliver:  deliver,
+	}
+
+	if o.async {
+		s.queue = vsync.NewOnceChan[envelope](o.buffer)
+		go s.consume()
+	}
+
+	b.mu.Lock()
+	b.subs = append(b.subs, s)
+	b.mu.Unlock()
+
+	if o.runner != nil {
+		s.stop = context.AfterFunc(o.runner.Context(), func() { s.Unsubscribe() })
+	}
+
+	return s, nil
+}
+
+func (b *Bus) remove(s *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, sub := range b.subs {
+		if sub == s {
+			b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+			return
+		}
+	}
+}
+
+// Publish publish the message to the topic, returns the count of the subscriptions matching the topic.
+// The subscribers are called in the order they subscribed.
+func (b *Bus) Publish(topic string, msg any) int {
+	segments := strings.Split(topic, ".")
+
+	b.mu.RLock()
+
+	var matched []*Subscription
+
+	for _, s := range b.subs {
+		if match(s.segments, segments) {
+			matched = append(matched, s)
+		}
+	}
+
+	b.mu.RUnlock()
+
+	for _, s := range matched {
+		s.publish(topic, msg)
+	}
+
+	return len(matched)
+}
+
+// Len returns the count of the subscriptions.
+func (b *Bus) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return len(b.subs)
+}
+
+// match returns whether the topic segments match the pattern segments.
+func match(pattern, topic []string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case wildcardMany:
+			for i := 0; i <= len(topic); i++ {
+				if match(pattern[1:], topic[i:]) {
+					return true
+				}
+			}
+
+			return false
+		case wildcardOne:
+			if len(topic) == 0 {
+				return false
+			}
+		default:
+			if len(topic) == 0 || topic[0] != pattern[0] {
+				return false
+			}
+		}
+
+		pattern = pattern[1:]
+		topic = topic[1:]
+	}
+
+	return len(topic) == 0
+}
@@ -0,0 +1,116 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vbus_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/vsync/vbus"
+	"github.com/vogo/vogo/vsync/vrun"
+)
+
+type order struct {
+	ID int
+}
+
+func TestSubscribe(t *testing.T) {
+	t.Parallel()
+
+	bus := vbus.New()
+
+	_, err := vbus.Subscribe(bus, "order.a*", func(string, *order) {})
+	assert.ErrorIs(t, err, vbus.ErrInvalidPattern)
+
+	var topics []string
+
+	sub, err := vbus.Subscribe(bus, "order.*", func(topic string, o *order) {
+		topics = append(topics, topic)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "order.*", sub.Pattern())
+
+	var created []int
+
+	_, err = vbus.Subscribe(bus, "**.created", func(_ string, o *order) {
+		created = append(created, o.ID)
+	})
+	assert.NoError(t, err)
+
+	// panics are isolated.
+	_, err = vbus.Subscribe(bus, "**", func(string, *order) {
+		panic("test panic")
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 3, bus.Publish("order.created", &order{ID: 1}))
+	assert.Equal(t, 2, bus.Publish("order.item.created", &order{ID: 2}))
+	assert.Equal(t, 2, bus.Publish("order.paid", &order{ID: 3}))
+
+	// ignored by the subscribers of other types.
+	assert.Equal(t, 3, bus.Publish("order.created", "not an order"))
+
+	assert.Equal(t, []string{"order.created", "order.paid"}, topics)
+	assert.Equal(t, []int{1, 2}, created)
+
+	assert.True(t, sub.Unsubscribe())
+	assert.False(t, sub.Unsubscribe())
+	assert.Equal(t, 2, bus.Len())
+}
+
+func TestAsync(t *testing.T) {
+	t.Parallel()
+
+	bus := vbus.New()
+	runner := vrun.New()
+
+	var (
+		mu       sync.Mutex
+		received []int
+	)
+
+	_, err := vbus.Subscribe(bus, "number", func(_ string, n int) {
+		mu.Lock()
+		received = append(received, n)
+		mu.Unlock()
+	}, vbus.Async(4), vbus.WithRunner(runner))
+	assert.NoError(t, err)
+
+	for i := range 100 {
+		bus.Publish("number", i)
+	}
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return len(received) == 100
+	}, time.Second, time.Millisecond)
+
+	for i, n := range received {
+		assert.Equal(t, i, n)
+	}
+
+	runner.Stop()
+
+	assert.Eventually(t, func() bool {
+		return bus.Len() == 0
+	}, time.Second, time.Millisecond)
+}
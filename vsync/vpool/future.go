@@ -0,0 +1,113 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vpool
+
+import (
+	"context"
+	"time"
+)
+
+// Future the result of a task submitted by SubmitFuture.
+type Future[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// Done returns a channel closed when the task finished.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait wait for the result of the task until the context is done.
+// The error is ErrPoolClosed if the task is dropped, or wraps ErrTaskPanic if the task panicked.
+func (f *Future[T]) Wait(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Result wait for the result of the task.
+func (f *Future[T]) Result() (T, error) {
+	<-f.done
+
+	return f.value, f.err
+}
+
+func (f *Future[T]) item(task func(ctx context.Context) (T, error)) *item {
+	return &item{
+		run: func(ctx context.Context) error {
+			value, err := task(ctx)
+			if err == nil {
+				f.value = value
+				close(f.done)
+			}
+
+			return err
+		},
+		fail: func(err error) {
+			f.err = err
+			close(f.done)
+		},
+	}
+}
+
+func newFuture[T any]() *Future[T] {
+	return &Future[T]{done: make(chan struct{})}
+}
+
+// SubmitFuture add a task returning a value to the queue, blocks until the queue has space or the pool is closed.
+func SubmitFuture[T any](p *Pool, task func(ctx context.Context) (T, error)) (*Future[T], error) {
+	f := newFuture[T]()
+
+	if err := p.submit(f.item(task), nil, true); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// TrySubmitFuture add a task returning a value to the queue, returns ErrQueueFull if the queue is full.
+func TrySubmitFuture[T any](p *Pool, task func(ctx context.Context) (T, error)) (*Future[T], error) {
+	f := newFuture[T]()
+
+	if err := p.submit(f.item(task), nil, false); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// SubmitFutureTimeout add a task returning a value to the queue,
+// returns ErrSubmitTimeout if the queue is still full after the timeout.
+func SubmitFutureTimeout[T any](p *Pool, task func(ctx context.Context) (T, error), timeout time.Duration) (*Future[T], error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	f := newFuture[T]()
+
+	if err := p.submit(f.item(task), timer.C, true); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
@@ -0,0 +1,408 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package vpool provides a bounded worker pool with a task queue.
+//
+//	pool := vpool.New(runner, vpool.WithWorkers(2, 16), vpool.WithQueueSize(256))
+//	err := pool.Submit(func(ctx context.Context) error { ... })
+//	future, err := vpool.SubmitFuture(pool, func(ctx context.Context) (int, error) { ... })
+//	n, err := future.Wait(ctx)
+package vpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vogo/vogo/vlog"
+	"github.com/vogo/vogo/vsync/vrun"
+)
+
+const (
+	DefaultQueueSize   = 1024
+	DefaultIdleTimeout = 30 * time.Second
+)
+
+var (
+	ErrPoolClosed    = errors.New("pool closed")
+	ErrQueueFull     = errors.New("pool queue full")
+	ErrSubmitTimeout = errors.New("pool submit timeout")
+	ErrTaskPanic     = errors.New("pool task panic")
+	ErrCloseTimeout  = errors.New("pool close timeout")
+)
+
+// Task a task run by the pool, the context is canceled when the pool is stopped.
+type Task func(ctx context.Context) error
+
+type options struct {
+	minWorkers   int
+	maxWorkers   int
+	queueSize    int
+	idleTimeout  time.Duration
+	errorHandler func(err error)
+}
+
+// Option the option of a pool.
+type Option func(o *options)
+
+// WithWorkers set the worker count, the pool is elastic if maxWorkers is greater than minWorkers,
+// default runtime.NumCPU() for both.
+func WithWorkers(minWorkers, maxWorkers int) Option {
+	return func(o *options) {
+		o.minWorkers = max(minWorkers, 1)
+		o.maxWorkers = max(maxWorkers, o.minWorkers)
+	}
+}
+
+// WithQueueSize set the size of the task queue, default DefaultQueueSize.
+func WithQueueSize(size int) Option {
+	return func(o *options) {
+		o.queueSize = max(size, 0)
+	}
+}
+
+// WithIdleTimeout set the timeout after which the idle workers beyond the min count exit, default DefaultIdleTimeout.
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.idleTimeout = timeout
+	}
+}
+
+// WithErrorHandler set the handler of the errors of the tasks submitted without futures, which are logged by default.
+func WithErrorHandler(handler func(err error)) Option {
+	return func(o *options) {
+		o.errorHandler = handler
+	}
+}
+
+// Stats the metrics of a pool.
+type Stats struct {
+	// Workers the count of the workers.
+	Workers int
+	// Active the count of the workers running tasks.
+	Active int
+	// Queued the count of the tasks waiting in the queue.
+	Queued int
+	// Completed the count of the tasks finished without error.
+	Completed uint64
+	// Failed the count of the tasks returning errors or panicking.
+	Failed uint64
+}
+
+type item struct {
+	run func(ctx context.Context) error
+	// fail handle the error of run, or ErrPoolClosed if the item is dropped.
+	fail func(err error)
+}
+
+// Pool a bounded worker pool, which is stopped when the runner is stopped.
+type Pool struct {
+	runner *vrun.Runner
+	opts   options
+	queue  chan *item
+
+	workers   atomic.Int64
+	active    atomic.Int64
+	completed atomic.Uint64
+	failed    atomic.Uint64
+
+	// cm guards closed, so that no task is added to pending after Close starts waiting.
+	cm      sync.RWMutex
+	closed  bool
+	pending sync.WaitGroup
+}
+
+// New create a pool as child of the runner, a new runner is used if it's nil.
+func New(runner *vrun.Runner, opts ...Option) *Pool {
+	if runner == nil {
+		runner = vrun.New()
+	} else {
+		runner = runner.NewChild()
+	}
+
+	o := options{
+		minWorkers:  runtime.NumCPU(),
+		maxWorkers:  runtime.NumCPU(),
+		queueSize:   DefaultQueueSize,
+		idleTimeout: DefaultIdleTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	p := &Pool{
+		runner: runner,
+		opts:   o,
+		queue:  make(chan *item, o.queueSize),
+	}
+
+	runner.Defer(p.drain)
+
+	for range o.minWorkers {
+		p.workers.Add(1)
+		runner.Go(p.work)
+	}
+
+	return p
+}
+
+// Submit add a task to the queue, blocks until the queue has space or the pool is closed.
+func (p *Pool) Submit(task Task) error {
+	return p.submit(&item{run: task, fail: p.handleError}, nil, true)
+}
+
+// TrySubmit add a task to the queue, returns ErrQueueFull if the queue is full.
+func (p *Pool) TrySubmit(task Task) error {
+	return p.submit(&item{run: task, fail: p.handleError}, nil, false)
+}
+
+// SubmitTimeout add a task to the queue, returns ErrSubmitTimeout if the queue is still full after the timeout.
+func (p *Pool) SubmitTimeout(task Task, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	return p.submit(&item{run: task, fail: p.handleError}, timer.C, true)
+}
+
+func (p *Pool) submit(it *item, timeout <-chan time.Time, block bool) error {
+	p.cm.RLock()
+
+	if p.closed {
+		p.cm.RUnlock()
+		return ErrPoolClosed
+	}
+
+	p.pending.Add(1)
+	p.cm.RUnlock()
+
+	if err := p.enqueue(it, timeout, block); err != nil {
+		p.pending.Done()
+		return err
+	}
+
+	// the task may be enqueued after the queue is drained on stop.
+	select {
+	case <-p.runner.C:
+		p.drain()
+	default:
+		p.grow()
+	}
+
+	return nil
+}
+
+func (p *Pool) enqueue(it *item, timeout <-chan time.Time, block bool) error {
+	select {
+	case <-p.runner.C:
+		return ErrPoolClosed
+	default:
+	}
+
+	if !block {
+		select {
+		case p.queue <- it:
+			return nil
+		default:
+			return ErrQueueFull
+		}
+	}
+
+	select {
+	case p.queue <- it:
+		return nil
+	case <-p.runner.C:
+		return ErrPoolClosed
+	case <-timeout:
+		return ErrSubmitTimeout
+	}
+}
+
+// grow start a new worker if all workers are busy and the max count is not reached.
+func (p *Pool) grow() {
+	for {
+		workers := p.workers.Load()
+
+		if workers >= int64(p.opts.maxWorkers) || p.active.Load()+int64(len(p.queue)) <= workers {
+			return
+		}
+
+		if p.workers.CompareAndSwap(workers, workers+1) {
+			p.runner.Go(p.work)
+			return
+		}
+	}
+}
+
+func (p *Pool) work(ctx context.Context) {
+	var (
+		timer *time.Timer
+		idle  <-chan time.Time
+	)
+
+	// only the workers of elastic pools exit on idle.
+	if p.opts.maxWorkers > p.opts.minWorkers {
+		timer = time.NewTimer(p.opts.idleTimeout)
+		defer timer.Stop()
+
+		idle = timer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.workers.Add(-1)
+			return
+		case it := <-p.queue:
+			p.run(ctx, it)
+		case <-idle:
+			if p.shrink() {
+				return
+			}
+		}
+
+		if timer != nil {
+			timer.Reset(p.opts.idleTimeout)
+		}
+	}
+}
+
+// shrink decrease the worker count if it's beyond the min count, returns whether the worker should exit.
+func (p *Pool) shrink() bool {
+	for {
+		workers := p.workers.Load()
+
+		if workers <= int64(p.opts.minWorkers) {
+			return false
+		}
+
+		if p.workers.CompareAndSwap(workers, workers-1) {
+			return true
+		}
+	}
+}
+
+func (p *Pool) run(ctx context.Context, it *item) {
+	p.active.Add(1)
+
+	defer func() {
+		p.active.Add(-1)
+		p.pending.Done()
+	}()
+
+	if err := safeRun(ctx, it.run); err != nil {
+		p.failed.Add(1)
+
+		if it.fail != nil {
+			it.fail(err)
+		}
+
+		return
+	}
+
+	p.completed.Add(1)
+}
+
+func safeRun(ctx context.Context, run func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			vlog.Errorf("pool task panic | panic: %v | stack: %s", r, debug.Stack())
+			err = fmt.Errorf("%w: %v", ErrTaskPanic, r)
+		}
+	}()
+
+	return run(ctx)
+}
+
+func (p *Pool) handleError(err error) {
+	if p.opts.errorHandler != nil {
+		p.opts.errorHandler(err)
+		return
+	}
+
+	vlog.Errorf("pool task failed | err: %v", err)
+}
+
+// drain drop the tasks left in the queue.
+func (p *Pool) drain() {
+	for {
+		select {
+		case it := <-p.queue:
+			if it.fail != nil {
+				it.fail(ErrPoolClosed)
+			}
+
+			p.pending.Done()
+		default:
+			return
+		}
+	}
+}
+
+// Stats returns the metrics of the pool.
+func (p *Pool) Stats() Stats {
+	return Stats{
+		Workers:   int(p.workers.Load()),
+		Active:    int(p.active.Load()),
+		Queued:    len(p.queue),
+		Completed: p.completed.Load(),
+		Failed:    p.failed.Load(),
+	}
+}
+
+// Runner returns the runner of the pool.
+func (p *Pool) Runner() *vrun.Runner {
+	return p.runner
+}
+
+// Stop stop the pool immediately, the tasks left in the queue are dropped with ErrPoolClosed.
+func (p *Pool) Stop() {
+	p.runner.Stop()
+}
+
+// Close stop accepting tasks, wait for the queued tasks to finish in the timeout, and then stop the pool.
+func (p *Pool) Close(timeout time.Duration) error {
+	p.cm.Lock()
+	p.closed = true
+	p.cm.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	finished := make(chan struct{})
+
+	go func() {
+		p.pending.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-timer.C:
+		p.Stop()
+		return ErrCloseTimeout
+	}
+
+	p.Stop()
+
+	return nil
+}
@@ -0,0 +1,162 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vpool_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/vsync/vpool"
+	"github.com/vogo/vogo/vsync/vrun"
+)
+
+var errTask = errors.New("task error")
+
+func TestSubmit(t *testing.T) {
+	t.Parallel()
+
+	var (
+		runs   int64
+		errCnt int64
+	)
+
+	pool := vpool.New(nil, vpool.WithWorkers(4, 4), vpool.WithErrorHandler(func(err error) {
+		atomic.AddInt64(&errCnt, 1)
+	}))
+
+	for i := range 100 {
+		assert.NoError(t, pool.Submit(func(context.Context) error {
+			atomic.AddInt64(&runs, 1)
+
+			switch i {
+			case 0:
+				return errTask
+			case 1:
+				panic("test panic")
+			}
+
+			return nil
+		}))
+	}
+
+	assert.NoError(t, pool.Close(time.Second))
+	assert.Equal(t, int64(100), atomic.LoadInt64(&runs))
+	assert.Equal(t, int64(2), atomic.LoadInt64(&errCnt))
+
+	stats := pool.Stats()
+	assert.Equal(t, uint64(98), stats.Completed)
+	assert.Equal(t, uint64(2), stats.Failed)
+
+	assert.ErrorIs(t, pool.Submit(func(context.Context) error { return nil }), vpool.ErrPoolClosed)
+}
+
+func TestFuture(t *testing.T) {
+	t.Parallel()
+
+	pool := vpool.New(nil, vpool.WithWorkers(1, 1))
+	defer pool.Stop()
+
+	f, err := vpool.SubmitFuture(pool, func(context.Context) (int, error) {
+		return 1, nil
+	})
+	assert.NoError(t, err)
+
+	n, err := f.Wait(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	f, err = vpool.SubmitFuture(pool, func(context.Context) (int, error) {
+		panic("test panic")
+	})
+	assert.NoError(t, err)
+
+	_, err = f.Result()
+	assert.ErrorIs(t, err, vpool.ErrTaskPanic)
+}
+
+func TestQueueFull(t *testing.T) {
+	t.Parallel()
+
+	runner := vrun.New()
+	pool := vpool.New(runner, vpool.WithWorkers(1, 1), vpool.WithQueueSize(1))
+
+	block := make(chan struct{})
+
+	blocking := func(context.Context) error {
+		<-block
+		return nil
+	}
+
+	assert.NoError(t, pool.Submit(blocking))
+
+	// wait for the worker to take the first task.
+	for pool.Stats().Active == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	assert.NoError(t, pool.TrySubmit(blocking))
+	assert.ErrorIs(t, pool.TrySubmit(blocking), vpool.ErrQueueFull)
+	assert.ErrorIs(t, pool.SubmitTimeout(blocking, 10*time.Millisecond), vpool.ErrSubmitTimeout)
+
+	f, err := vpool.SubmitFutureTimeout(pool, func(context.Context) (int, error) {
+		return 1, nil
+	}, 10*time.Millisecond)
+	assert.Nil(t, f)
+	assert.ErrorIs(t, err, vpool.ErrSubmitTimeout)
+
+	stats := pool.Stats()
+	assert.Equal(t, 1, stats.Workers)
+	assert.Equal(t, 1, stats.Active)
+	assert.Equal(t, 1, stats.Queued)
+
+	// pool is stopped with the runner, the queued task is dropped.
+	runner.Stop()
+	close(block)
+
+	assert.ErrorIs(t, pool.Submit(blocking), vpool.ErrPoolClosed)
+	assert.Equal(t, 0, pool.Stats().Queued)
+}
+
+func TestElastic(t *testing.T) {
+	t.Parallel()
+
+	pool := vpool.New(nil, vpool.WithWorkers(1, 4), vpool.WithIdleTimeout(10*time.Millisecond))
+	defer pool.Stop()
+
+	block := make(chan struct{})
+
+	for range 4 {
+		assert.NoError(t, pool.Submit(func(context.Context) error {
+			<-block
+			return nil
+		}))
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 4, pool.Stats().Workers)
+	assert.Equal(t, 4, pool.Stats().Active)
+
+	close(block)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 1, pool.Stats().Workers)
+}
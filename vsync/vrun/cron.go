@@ -0,0 +1,153 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vrun
+
+import (
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"github.com/vogo/vogo/vlog"
+	"github.com/vogo/vogo/vtime"
+	"github.com/vogo/vogo/vtime/vcron"
+)
+
+type cronOptions struct {
+	policy    Policy
+	location  *time.Location
+	jitter    time.Duration
+	noOverlap bool
+}
+
+// CronOption the option of a cron task.
+type CronOption func(o *cronOptions)
+
+// WithCronPolicy set the failure policy of a cron task, only PolicyStop takes effect,
+// and a failed run is followed by the next scheduled run otherwise.
+func WithCronPolicy(policy Policy) CronOption {
+	return func(o *cronOptions) {
+		o.policy = policy
+	}
+}
+
+// WithLocation set the time location of a cron task, default vtime.TimeLocation.
+func WithLocation(location *time.Location) CronOption {
+	return func(o *cronOptions) {
+		o.location = location
+	}
+}
+
+// WithJitter delay each run of a cron task by a random duration in [0, jitter).
+func WithJitter(jitter time.Duration) CronOption {
+	return func(o *cronOptions) {
+		o.jitter = jitter
+	}
+}
+
+// WithoutOverlap skip a run of a cron task if the previous run has not finished.
+func WithoutOverlap() CronOption {
+	return func(o *cronOptions) {
+		o.noOverlap = true
+	}
+}
+
+// Cron run task on the cron schedule util the runner is stopped, see vcron.Parse for the spec.
+// Each run is in a goroutine tracked by the runner, and a failure stops the runner only with WithCronPolicy(PolicyStop).
+//
+//	err := s.Cron("0 */5 * * * *", task, vrun.WithJitter(time.Second), vrun.WithoutOverlap())
+func (s *Runner) Cron(spec string, task Task, opts ...CronOption) error {
+	return s.cron(spec, funcName(task), wrapTask(task), opts)
+}
+
+// CronE run the error task on the cron schedule util the runner is stopped, see Cron.
+func (s *Runner) CronE(spec string, task ErrorTask, opts ...CronOption) error {
+	return s.cron(spec, funcName(task), task, opts)
+}
+
+func (s *Runner) cron(spec, name string, task ErrorTask, opts []CronOption) error {
+	schedule, err := vcron.Parse(spec)
+	if err != nil {
+		return err
+	}
+
+	o := &cronOptions{policy: PolicyRestart}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	loc := o.location
+	if loc == nil {
+		loc = vtime.TimeLocation
+	}
+
+	var running atomic.Bool
+
+	exec := func() {
+		defer running.Store(false)
+
+		if taskErr := s.safeRun(name, task); taskErr != nil {
+			s.reportError(taskErr)
+
+			if o.policy == PolicyStop {
+				s.Stop()
+			}
+		}
+	}
+
+	s.goTask(name, func() {
+		var timer *time.Timer
+
+		for {
+			now := time.Now().In(loc)
+
+			next := schedule.Next(now)
+			if next.IsZero() {
+				vlog.Warnf("cron schedule never fires | task: %s | spec: %s", name, spec)
+				return
+			}
+
+			delay := next.Sub(now)
+			if o.jitter > 0 {
+				delay += rand.N(o.jitter)
+			}
+
+			if timer == nil {
+				timer = time.NewTimer(delay)
+				defer timer.Stop()
+			} else {
+				timer.Reset(delay)
+			}
+
+			select {
+			case <-s.C:
+				return
+			case <-timer.C:
+			}
+
+			if o.noOverlap && !running.CompareAndSwap(false, true) {
+				vlog.Warnf("cron task skipped as previous run not finished | task: %s", name)
+				continue
+			}
+
+			running.Store(true)
+			s.goTask(name, exec)
+		}
+	})
+
+	return nil
+}
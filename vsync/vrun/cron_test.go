@@ -0,0 +1,83 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vrun_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/vsync/vrun"
+	"github.com/vogo/vogo/vtime/vcron"
+)
+
+func TestCron(t *testing.T) {
+	t.Parallel()
+
+	s := vrun.New()
+
+	assert.ErrorIs(t, s.Cron("* * *", func() {}), vcron.ErrInvalidSpec)
+
+	var (
+		runs       int64
+		slowRuns   int64
+		concurrent int64
+	)
+
+	assert.NoError(t, s.Cron("* * * * * *", func() {
+		atomic.AddInt64(&runs, 1)
+	}, vrun.WithLocation(time.UTC), vrun.WithJitter(10*time.Millisecond)))
+
+	assert.NoError(t, s.CronE("* * * * * *", func(context.Context) error {
+		if atomic.AddInt64(&concurrent, 1) > 1 {
+			t.Error("overlapped cron task")
+		}
+
+		atomic.AddInt64(&slowRuns, 1)
+		time.Sleep(1500 * time.Millisecond)
+		atomic.AddInt64(&concurrent, -1)
+
+		return nil
+	}, vrun.WithoutOverlap()))
+
+	time.Sleep(2500 * time.Millisecond)
+
+	assert.NoError(t, s.StopAndWait(2*time.Second))
+	assert.GreaterOrEqual(t, atomic.LoadInt64(&runs), int64(2))
+	assert.LessOrEqual(t, atomic.LoadInt64(&slowRuns), int64(2))
+}
+
+func TestCronPolicyStop(t *testing.T) {
+	t.Parallel()
+
+	s := vrun.New()
+
+	assert.NoError(t, s.CronE("* * * * * *", func(context.Context) error {
+		return assert.AnError
+	}, vrun.WithCronPolicy(vrun.PolicyStop)))
+
+	select {
+	case <-s.C:
+	case <-time.After(2 * time.Second):
+		t.Fatal("runner not stopped by the failed cron task")
+	}
+
+	assert.NoError(t, s.StopAndWait(time.Second))
+}
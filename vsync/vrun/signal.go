@@ -0,0 +1,70 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vrun
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+
+	"github.com/vogo/vogo/vlog"
+)
+
+var (
+	rootOnce sync.Once
+	root     *Runner
+)
+
+// Root returns the root runner of the process, which is stopped by OnSignals.
+func Root() *Runner {
+	rootOnce.Do(func() {
+		root = New()
+	})
+
+	return root
+}
+
+// OnSignals stop the root runner when receiving any of the signals, and returns the root runner.
+//
+//	r := vrun.OnSignals(syscall.SIGINT, syscall.SIGTERM)
+//	<-r.C
+//	err := r.StopAndWait(10 * time.Second)
+func OnSignals(signals ...os.Signal) *Runner {
+	r := Root()
+	r.OnSignals(signals...)
+
+	return r
+}
+
+// OnSignals stop the runner when receiving any of the signals.
+// The signals are no longer relayed to the runner after it's stopped.
+func (s *Runner) OnSignals(signals ...os.Signal) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, signals...)
+
+	go func() {
+		defer signal.Stop(c)
+
+		select {
+		case sig := <-c:
+			vlog.Infof("runner received signal, stopping | signal: %s", sig)
+			s.Stop()
+		case <-s.C:
+		}
+	}()
+}
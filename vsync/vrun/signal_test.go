@@ -0,0 +1,49 @@
+//go:build !windows
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vrun_test
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/vsync/vrun"
+)
+
+func TestOnSignals(t *testing.T) {
+	t.Parallel()
+
+	// the global root runner is not stopped, which may be used by other tests.
+	assert.Same(t, vrun.Root(), vrun.Root())
+
+	r := vrun.New()
+	r.OnSignals(syscall.SIGUSR1)
+
+	time.Sleep(goroutineScheduleInterval)
+
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+
+	select {
+	case <-r.C:
+	case <-time.After(time.Second):
+		t.Fatal("runner not stopped after signal")
+	}
+}
@@ -0,0 +1,382 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/vogo/vogo/vlog"
+)
+
+const (
+	DefaultMaxRestarts        = 3
+	DefaultRestartWindow      = 5 * time.Second
+	DefaultServiceStopTimeout = 10 * time.Second
+)
+
+var (
+	ErrDuplicateService  = errors.New("duplicate service")
+	ErrSupervisorStopped = errors.New("supervisor stopped")
+	// ErrRestartIntensity services restarted too many times in the window, and the supervisor gave up.
+	ErrRestartIntensity = errors.New("restart intensity exceeded")
+	// ErrServiceExited a service returned without error while the supervisor is running.
+	ErrServiceExited = errors.New("service exited")
+)
+
+// Strategy the restart strategy of a supervisor.
+type Strategy int
+
+const (
+	// OneForOne restart the exited service only.
+	OneForOne Strategy = iota
+	// OneForAll stop all other services and restart all of them.
+	OneForAll
+	// RestForOne stop the services started after the exited one, and restart them with the exited one.
+	RestForOne
+)
+
+// ServiceState the state of a supervised service.
+type ServiceState int
+
+const (
+	ServiceRunning ServiceState = iota
+	ServiceRestarting
+	ServiceStopped
+	ServiceFailed
+)
+
+func (s ServiceState) String() string {
+	switch s {
+	case ServiceRunning:
+		return "running"
+	case ServiceRestarting:
+		return "restarting"
+	case ServiceStopped:
+		return "stopped"
+	case ServiceFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// ServiceStatus the status snapshot of a service.
+type ServiceStatus struct {
+	Name      string
+	State     ServiceState
+	Restarts  int
+	LastError error
+	StartedAt time.Time
+}
+
+type supervisorOptions struct {
+	strategy    Strategy
+	maxRestarts int
+	window      time.Duration
+	stopTimeout time.Duration
+}
+
+// SupervisorOption the option of a supervisor.
+type SupervisorOption func(o *supervisorOptions)
+
+// WithStrategy set the restart strategy, default OneForOne.
+func WithStrategy(strategy Strategy) SupervisorOption {
+	return func(o *supervisorOptions) {
+		o.strategy = strategy
+	}
+}
+
+// WithIntensity set the max restarts in the window, the supervisor stops if exceeded,
+// default DefaultMaxRestarts in DefaultRestartWindow.
+func WithIntensity(maxRestarts int, window time.Duration) SupervisorOption {
+	return func(o *supervisorOptions) {
+		o.maxRestarts = maxRestarts
+		o.window = window
+	}
+}
+
+// WithServiceStopTimeout set the timeout to wait for a service to stop, default DefaultServiceStopTimeout.
+func WithServiceStopTimeout(timeout time.Duration) SupervisorOption {
+	return func(o *supervisorOptions) {
+		o.stopTimeout = timeout
+	}
+}
+
+type service struct {
+	name   string
+	start  ErrorTask
+	runner *Runner
+	gen    uint64
+
+	state     ServiceState
+	restarts  int
+	lastError error
+	startedAt time.Time
+}
+
+type serviceExit struct {
+	svc *service
+	gen uint64
+	err *TaskError
+}
+
+// Supervisor run named services and restart them by the strategy when they exit, like the supervisor of Erlang.
+// The services are stopped in reverse start order when the supervisor is stopped.
+//
+//	sup := vrun.NewSupervisor(vrun.WithStrategy(vrun.RestForOne))
+//	_ = sup.Add("db", runDB)
+//	_ = sup.Add("api", runAPI)
+//	defer sup.Stop()
+type Supervisor struct {
+	*Runner
+
+	opts  supervisorOptions
+	exits chan serviceExit
+
+	sm       sync.Mutex
+	closed   bool
+	services []*service
+	index    map[string]*service
+	restarts []time.Time
+}
+
+// NewSupervisor create a new supervisor.
+func NewSupervisor(opts ...SupervisorOption) *Supervisor {
+	return newSupervisor(New(), opts)
+}
+
+// NewSupervisor create a new supervisor as child of the runner.
+func (s *Runner) NewSupervisor(opts ...SupervisorOption) *Supervisor {
+	return newSupervisor(s.NewChild(), opts)
+}
+
+func newSupervisor(r *Runner, opts []SupervisorOption) *Supervisor {
+	sup := &Supervisor{
+		Runner: r,
+		opts: supervisorOptions{
+			strategy:    OneForOne,
+			maxRestarts: DefaultMaxRestarts,
+			window:      DefaultRestartWindow,
+			stopTimeout: DefaultServiceStopTimeout,
+		},
+		exits: make(chan serviceExit),
+		index: make(map[string]*service),
+	}
+
+	for _, opt := range opts {
+		opt(&sup.opts)
+	}
+
+	r.Defer(sup.stopAll)
+	r.goTask("supervisor", sup.loop)
+
+	return sup
+}
+
+// Add start a service, which is restarted by the strategy of the supervisor when it exits.
+// The start func should block until the context is canceled.
+func (sup *Supervisor) Add(name string, start ErrorTask) error {
+	sup.sm.Lock()
+	defer sup.sm.Unlock()
+
+	if sup.closed {
+		return ErrSupervisorStopped
+	}
+
+	if _, ok := sup.index[name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateService, name)
+	}
+
+	svc := &service{name: name, start: start}
+	sup.services = append(sup.services, svc)
+	sup.index[name] = svc
+
+	sup.startService(svc)
+
+	return nil
+}
+
+// Status returns the status of the services in start order.
+func (sup *Supervisor) Status() []ServiceStatus {
+	sup.sm.Lock()
+	defer sup.sm.Unlock()
+
+	status := make([]ServiceStatus, len(sup.services))
+
+	for i, svc := range sup.services {
+		status[i] = ServiceStatus{
+			Name:      svc.name,
+			State:     svc.state,
+			Restarts:  svc.restarts,
+			LastError: svc.lastError,
+			StartedAt: svc.startedAt,
+		}
+	}
+
+	return status
+}
+
+func (sup *Supervisor) loop() {
+	for {
+		select {
+		case <-sup.C:
+			return
+		case e := <-sup.exits:
+			if !sup.handleExit(e) {
+				sup.Stop()
+				return
+			}
+		}
+	}
+}
+
+// handleExit restart services by the strategy, returns false if the restart intensity exceeded.
+func (sup *Supervisor) handleExit(e serviceExit) bool {
+	sup.sm.Lock()
+	defer sup.sm.Unlock()
+
+	if sup.closed || e.gen != e.svc.gen {
+		return true
+	}
+
+	e.svc.lastError = e.err
+
+	now := time.Now()
+	restarts := sup.restarts[:0]
+
+	for _, t := range sup.restarts {
+		if now.Sub(t) < sup.opts.window {
+			restarts = append(restarts, t)
+		}
+	}
+
+	sup.restarts = append(restarts, now)
+
+	if len(sup.restarts) > sup.opts.maxRestarts {
+		e.svc.state = ServiceFailed
+		sup.reportError(&TaskError{Task: e.svc.name, Err: ErrRestartIntensity})
+
+		return false
+	}
+
+	affected := []*service{e.svc}
+
+	switch sup.opts.strategy {
+	case OneForAll:
+		affected = slices.Clone(sup.services)
+	case RestForOne:
+		for i, svc := range sup.services {
+			if svc == e.svc {
+				affected = slices.Clone(sup.services[i:])
+				break
+			}
+		}
+	case OneForOne:
+	}
+
+	runners := make([]*Runner, len(affected))
+
+	for i, svc := range affected {
+		svc.state = ServiceRestarting
+		runners[i] = svc.runner
+	}
+
+	// stop services without sm locked, which may take up to the stop timeout.
+	sup.sm.Unlock()
+	stopRunners(affected, runners, sup.opts.stopTimeout)
+	sup.sm.Lock()
+
+	if sup.closed {
+		return true
+	}
+
+	for _, svc := range affected {
+		svc.restarts++
+		sup.startService(svc)
+	}
+
+	return true
+}
+
+// startService run a new instance of the service, must be called with sm locked.
+func (sup *Supervisor) startService(svc *service) {
+	r := newRunner(context.WithoutCancel(sup.ctx))
+
+	svc.runner = r
+	svc.gen++
+	svc.state = ServiceRunning
+	svc.startedAt = time.Now()
+
+	gen := svc.gen
+
+	r.goTask(svc.name, func() {
+		err := r.safeRun(svc.name, svc.start)
+
+		if err != nil {
+			sup.reportError(err)
+		} else {
+			err = &TaskError{Task: svc.name, Err: ErrServiceExited}
+		}
+
+		// the exit of a stopped instance is expected.
+		select {
+		case sup.exits <- serviceExit{svc: svc, gen: gen, err: err}:
+		case <-r.C:
+		case <-sup.C:
+		}
+	})
+}
+
+// stopRunners stop the running instances of the services in reverse order, must be called with sm unlocked.
+func stopRunners(services []*service, runners []*Runner, timeout time.Duration) {
+	for i := len(runners) - 1; i >= 0; i-- {
+		if err := runners[i].StopAndWait(timeout); err != nil {
+			vlog.Errorf("supervisor stop service failed | service: %s | err: %v", services[i].name, err)
+		}
+	}
+}
+
+// stopAll stop the services in reverse start order.
+func (sup *Supervisor) stopAll() {
+	sup.sm.Lock()
+	sup.closed = true
+	services := slices.Clone(sup.services)
+	runners := make([]*Runner, len(services))
+
+	for i, svc := range services {
+		runners[i] = svc.runner
+	}
+
+	sup.sm.Unlock()
+
+	stopRunners(services, runners, sup.opts.stopTimeout)
+
+	sup.sm.Lock()
+	defer sup.sm.Unlock()
+
+	for _, svc := range services {
+		if svc.state != ServiceFailed {
+			svc.state = ServiceStopped
+		}
+	}
+}
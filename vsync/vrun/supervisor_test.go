@@ -0,0 +1,181 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vrun_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/vsync/vrun"
+)
+
+// recorder records the starts and stops of services.
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(event string) {
+	r.mu.Lock()
+	r.events = append(r.events, event)
+	r.mu.Unlock()
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.events...)
+}
+
+// service returns a service which fails once fail is set.
+func (r *recorder) service(name string, fail *atomic.Bool) vrun.ErrorTask {
+	return func(ctx context.Context) error {
+		r.add("start " + name)
+		defer r.add("stop " + name)
+
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				if fail != nil && fail.CompareAndSwap(true, false) {
+					return errTask
+				}
+			}
+		}
+	}
+}
+
+func TestSupervisorStopOrder(t *testing.T) {
+	t.Parallel()
+
+	r := &recorder{}
+	sup := vrun.NewSupervisor()
+
+	assert.NoError(t, sup.Add("a", r.service("a", nil)))
+	assert.NoError(t, sup.Add("b", r.service("b", nil)))
+	assert.NoError(t, sup.Add("c", r.service("c", nil)))
+	assert.ErrorIs(t, sup.Add("a", r.service("a", nil)), vrun.ErrDuplicateService)
+
+	time.Sleep(goroutineScheduleInterval)
+
+	assert.NoError(t, sup.StopAndWait(time.Second))
+	assert.Equal(t, []string{"stop c", "stop b", "stop a"}, r.get()[3:])
+	assert.ErrorIs(t, sup.Add("d", r.service("d", nil)), vrun.ErrSupervisorStopped)
+
+	for _, status := range sup.Status() {
+		assert.Equal(t, vrun.ServiceStopped, status.State)
+	}
+}
+
+func TestSupervisorRestForOne(t *testing.T) {
+	t.Parallel()
+
+	r := &recorder{}
+	sup := vrun.NewSupervisor(vrun.WithStrategy(vrun.RestForOne))
+
+	var fail atomic.Bool
+
+	assert.NoError(t, sup.Add("a", r.service("a", nil)))
+	assert.NoError(t, sup.Add("b", r.service("b", &fail)))
+	assert.NoError(t, sup.Add("c", r.service("c", nil)))
+
+	time.Sleep(goroutineScheduleInterval)
+
+	fail.Store(true)
+
+	time.Sleep(goroutineScheduleInterval)
+
+	events := r.get()[3:]
+	assert.Equal(t, []string{"stop b", "stop c"}, events[:2])
+	assert.ElementsMatch(t, []string{"start b", "start c"}, events[2:])
+
+	status := sup.Status()
+	assert.Equal(t, 0, status[0].Restarts)
+	assert.Equal(t, 1, status[1].Restarts)
+	assert.ErrorIs(t, status[1].LastError, errTask)
+	assert.Equal(t, 1, status[2].Restarts)
+	assert.Equal(t, vrun.ServiceRunning, status[2].State)
+
+	assert.NoError(t, sup.StopAndWait(time.Second))
+}
+
+func TestSupervisorIntensity(t *testing.T) {
+	t.Parallel()
+
+	var failures int64
+
+	sup := vrun.NewSupervisor(vrun.WithStrategy(vrun.OneForAll), vrun.WithIntensity(2, time.Minute))
+	sup.OnError(func(err *vrun.TaskError) {
+		if err.Err == vrun.ErrRestartIntensity {
+			atomic.AddInt64(&failures, 1)
+		}
+	})
+
+	assert.NoError(t, sup.Add("crash", func(context.Context) error {
+		return errTask
+	}))
+
+	select {
+	case <-sup.C:
+	case <-time.After(time.Second):
+		t.Fatal("supervisor not stopped")
+	}
+
+	assert.Equal(t, int64(1), atomic.LoadInt64(&failures))
+
+	status := sup.Status()
+	assert.Equal(t, vrun.ServiceFailed, status[0].State)
+	assert.Equal(t, 2, status[0].Restarts)
+}
+
+func TestSupervisorStatusWhileStopping(t *testing.T) {
+	t.Parallel()
+
+	sup := vrun.NewSupervisor()
+
+	// a service slow to stop.
+	assert.NoError(t, sup.Add("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(500 * time.Millisecond)
+
+		return nil
+	}))
+
+	time.Sleep(goroutineScheduleInterval)
+
+	go sup.Stop()
+
+	time.Sleep(goroutineScheduleInterval)
+
+	// the status is available while the services are being stopped.
+	start := time.Now()
+	assert.Len(t, sup.Status(), 1)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	assert.NoError(t, sup.StopAndWait(time.Second))
+	assert.Equal(t, vrun.ServiceStopped, sup.Status()[0].State)
+}
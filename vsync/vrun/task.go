@@ -0,0 +1,234 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/vogo/vogo/vlog"
+)
+
+const (
+	DefaultMinBackoff = 100 * time.Millisecond
+	DefaultMaxBackoff = 30 * time.Second
+)
+
+// ErrTaskPanic the task panicked.
+var ErrTaskPanic = errors.New("task panic")
+
+// ErrorTask a task returning an error, it should return when the context is canceled.
+type ErrorTask func(ctx context.Context) error
+
+// TaskError the failure of a task, which is an error returned by the task or a recovered panic.
+type TaskError struct {
+	// Task the name of the task.
+	Task string
+	// Err the error returned by the task, or ErrTaskPanic.
+	Err error
+	// Panic the recovered value if the task panicked.
+	Panic any
+	// Stack the stack trace if the task panicked.
+	Stack []byte
+}
+
+func (e *TaskError) Error() string {
+	if e.Panic != nil {
+		return fmt.Sprintf("task %s panic: %v", e.Task, e.Panic)
+	}
+
+	return fmt.Sprintf("task %s failed: %v", e.Task, e.Err)
+}
+
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// Policy the policy to handle failures of a task.
+type Policy int
+
+const (
+	// PolicyRestart run the task again after a backoff, which doubles on consecutive failures.
+	PolicyRestart Policy = iota
+	// PolicyStop stop the runner.
+	PolicyStop
+	// PolicyIgnore run the task again as usual.
+	PolicyIgnore
+)
+
+type taskOptions struct {
+	policy     Policy
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// TaskOption the option of a task.
+type TaskOption func(o *taskOptions)
+
+// WithPolicy set the failure policy of the task, default PolicyRestart.
+func WithPolicy(policy Policy) TaskOption {
+	return func(o *taskOptions) {
+		o.policy = policy
+	}
+}
+
+// WithBackoff set the backoff range of PolicyRestart, default DefaultMinBackoff to DefaultMaxBackoff.
+func WithBackoff(minBackoff, maxBackoff time.Duration) TaskOption {
+	return func(o *taskOptions) {
+		o.minBackoff = minBackoff
+		o.maxBackoff = maxBackoff
+	}
+}
+
+func newTaskOptions(opts []TaskOption) *taskOptions {
+	o := &taskOptions{
+		policy:     PolicyRestart,
+		minBackoff: DefaultMinBackoff,
+		maxBackoff: DefaultMaxBackoff,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// OnError add a handler called on failures of the tasks of the runner.
+// The handler is called in the goroutine of the failed task, so it should return quickly.
+func (s *Runner) OnError(handler func(err *TaskError)) {
+	s.tm.Lock()
+	s.errorHandlers = append(s.errorHandlers, handler)
+	s.tm.Unlock()
+}
+
+// safeRun run the task and recover the panic, returns the failure if any.
+func (s *Runner) safeRun(name string, task ErrorTask) (taskErr *TaskError) {
+	defer func() {
+		if r := recover(); r != nil {
+			taskErr = &TaskError{Task: name, Err: ErrTaskPanic, Panic: r, Stack: debug.Stack()}
+		}
+	}()
+
+	if err := task(s.ctx); err != nil {
+		return &TaskError{Task: name, Err: err}
+	}
+
+	return nil
+}
+
+// reportError log the failure and call the error handlers.
+func (s *Runner) reportError(err *TaskError) {
+	if err.Panic != nil {
+		vlog.Errorf("runner task panic | task: %s | panic: %v | stack: %s", err.Task, err.Panic, err.Stack)
+	} else {
+		vlog.Errorf("runner task failed | task: %s | err: %v", err.Task, err.Err)
+	}
+
+	s.tm.Lock()
+	handlers := s.errorHandlers
+	s.tm.Unlock()
+
+	for _, handler := range handlers {
+		handler(err)
+	}
+}
+
+// taskRunner run a task repeatedly and applies the failure policy.
+type taskRunner struct {
+	s       *Runner
+	name    string
+	task    ErrorTask
+	opts    *taskOptions
+	backoff time.Duration
+}
+
+func (s *Runner) newTaskRunner(name string, task ErrorTask, opts []TaskOption) *taskRunner {
+	return &taskRunner{
+		s:    s,
+		name: name,
+		task: task,
+		opts: newTaskOptions(opts),
+	}
+}
+
+// run the task once, returns false if the runner is stopped.
+func (t *taskRunner) run() bool {
+	err := t.s.safeRun(t.name, t.task)
+	if err == nil {
+		t.backoff = 0
+		return true
+	}
+
+	t.s.reportError(err)
+
+	switch t.opts.policy {
+	case PolicyStop:
+		t.s.Stop()
+		return false
+	case PolicyRestart:
+		if t.backoff == 0 {
+			t.backoff = t.opts.minBackoff
+		} else {
+			t.backoff = min(t.backoff*2, t.opts.maxBackoff)
+		}
+
+		timer := time.NewTimer(t.backoff)
+		defer timer.Stop()
+
+		select {
+		case <-t.s.C:
+			return false
+		case <-timer.C:
+		}
+	case PolicyIgnore:
+	}
+
+	return true
+}
+
+// LoopE run the error task util the runner is stopped, failures are handled by the policy of the task.
+// Note, there is not an interval between the executions of two tasks.
+func (s *Runner) LoopE(task ErrorTask, opts ...TaskOption) {
+	s.loop(s.newTaskRunner(funcName(task), task, opts))
+}
+
+// IntervalE run the error task at intervals util the runner is stopped,
+// failures are handled by the policy of the task.
+func (s *Runner) IntervalE(task ErrorTask, interval time.Duration, opts ...TaskOption) {
+	s.interval(s.newTaskRunner(funcName(task), task, opts), interval)
+}
+
+// wrapTask wrap a task as an error task.
+func wrapTask(task Task) ErrorTask {
+	return func(context.Context) error {
+		task()
+		return nil
+	}
+}
+
+// wrapContextTask wrap a context task as an error task.
+func wrapContextTask(task ContextTask) ErrorTask {
+	return func(ctx context.Context) error {
+		task(ctx)
+		return nil
+	}
+}
@@ -0,0 +1,112 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vrun_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/vsync/vrun"
+)
+
+var errTask = errors.New("task error")
+
+func TestPolicyStop(t *testing.T) {
+	t.Parallel()
+
+	s := vrun.New()
+
+	var failures int64
+
+	s.OnError(func(err *vrun.TaskError) {
+		assert.ErrorIs(t, err, errTask)
+		atomic.AddInt64(&failures, 1)
+	})
+
+	s.LoopE(func(context.Context) error {
+		return errTask
+	}, vrun.WithPolicy(vrun.PolicyStop))
+
+	select {
+	case <-s.C:
+	case <-time.After(time.Second):
+		t.Fatal("runner not stopped by failed task")
+	}
+
+	assert.Equal(t, int64(1), atomic.LoadInt64(&failures))
+}
+
+func TestPolicyRestart(t *testing.T) {
+	t.Parallel()
+
+	s := vrun.New()
+	defer s.Stop()
+
+	var (
+		runs   int64
+		panics int64
+	)
+
+	s.OnError(func(err *vrun.TaskError) {
+		assert.ErrorIs(t, err, vrun.ErrTaskPanic)
+		assert.NotEmpty(t, err.Stack)
+		atomic.AddInt64(&panics, 1)
+	})
+
+	s.LoopE(func(context.Context) error {
+		if atomic.AddInt64(&runs, 1) <= 2 {
+			panic("test panic")
+		}
+
+		time.Sleep(time.Millisecond)
+
+		return nil
+	}, vrun.WithBackoff(time.Millisecond, 10*time.Millisecond))
+
+	// plain tasks are panic-safe too.
+	s.Interval(func() {
+		panic("test panic")
+	}, time.Hour)
+
+	time.Sleep(goroutineScheduleInterval * 5)
+
+	assert.Equal(t, int64(3), atomic.LoadInt64(&panics))
+	assert.Greater(t, atomic.LoadInt64(&runs), int64(3))
+}
+
+func TestPolicyIgnore(t *testing.T) {
+	t.Parallel()
+
+	s := vrun.New()
+
+	var runs int64
+
+	s.IntervalE(func(context.Context) error {
+		atomic.AddInt64(&runs, 1)
+		return errTask
+	}, time.Millisecond, vrun.WithPolicy(vrun.PolicyIgnore))
+
+	time.Sleep(goroutineScheduleInterval)
+
+	assert.NoError(t, s.StopAndWait(time.Second))
+	assert.Greater(t, atomic.LoadInt64(&runs), int64(2))
+}
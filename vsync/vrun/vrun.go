@@ -18,6 +18,7 @@
 package vrun
 
 import (
+	"context"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -25,6 +26,9 @@ import (
 
 type Task func()
 
+// ContextTask a task accepting the context of the runner, which is canceled when the runner is stopped.
+type ContextTask func(ctx context.Context)
+
 // Runner the runner status struct.
 type Runner struct {
 	// channel to control stop status, stop it by calling Stop().
@@ -33,6 +37,33 @@ type Runner struct {
 	done   uint32
 	m      sync.Mutex
 	defers []Task
+
+	// stopDone is closed after the children are stopped and the defers are called.
+	stopDone chan struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// cm guards the relations, which is separated from m to avoid dead lock when parents and children stop each other.
+	cm       sync.Mutex
+	stopped  bool
+	children map[*Runner]struct{}
+	parents  []*Runner
+
+	// tm guards the tracking of the goroutines launched by the runner.
+	tm          sync.Mutex
+	wg          sync.WaitGroup
+	taskID      uint64
+	running     map[uint64]string
+	tasksClosed bool
+	current     atomic.Value // name of the defer being called
+
+	errorHandlers []func(err *TaskError)
+}
+
+// Context returns the context of the runner, which is canceled when the runner is stopped.
+func (s *Runner) Context() context.Context {
+	return s.ctx
 }
 
 // Defer add task called in desc order when stopper is stopped.
@@ -42,33 +73,113 @@ func (s *Runner) Defer(task Task) {
 	})
 }
 
-// doStop do stop work, include closing the chan and calling all defers.
-func (s *Runner) doStop() {
-	defer atomic.StoreUint32(&s.done, 1)
+// doStop do stop work after the runner is marked done, include stopping children and calling all defers.
+// It's called without holding m, so that children and defers can stop the runner again.
+func (s *Runner) doStop(defers []Task) {
+	defer close(s.stopDone)
+
+	// no more goroutines after stopped.
+	s.tm.Lock()
+	s.tasksClosed = true
+	s.tm.Unlock()
 
-	close(s.C)
+	s.cm.Lock()
+	s.stopped = true
+	children := s.children
+	parents := s.parents
+	s.children = nil
+	s.parents = nil
+	s.cm.Unlock()
+
+	for child := range children {
+		child.Stop()
+	}
 
 	// call in desc order, like defer.
-	for i := len(s.defers) - 1; i >= 0; i-- {
-		s.defers[i]()
+	for i := len(defers) - 1; i >= 0; i-- {
+		s.current.Store(funcName(defers[i]))
+		defers[i]()
+	}
+
+	s.current.Store("")
+
+	for _, parent := range parents {
+		parent.removeChild(s)
+	}
+}
+
+// addChild add a child which will be stopped when the runner is stopped.
+func (s *Runner) addChild(child *Runner) {
+	s.cm.Lock()
+
+	if s.stopped {
+		s.cm.Unlock()
+		child.Stop()
+
+		return
+	}
+
+	s.children[child] = struct{}{}
+	s.cm.Unlock()
+
+	child.cm.Lock()
+
+	if child.stopped {
+		child.cm.Unlock()
+		s.removeChild(child)
+
+		return
 	}
 
-	// help gc
-	s.defers = nil
+	child.parents = append(child.parents, s)
+	child.cm.Unlock()
+}
+
+func (s *Runner) removeChild(child *Runner) {
+	s.cm.Lock()
+	delete(s.children, child)
+	s.cm.Unlock()
 }
 
 // Stop close the stopper.
+// Stopping a runner being stopped returns at once, e.g. by its defers or children, use StopAndWait to wait for it.
 func (s *Runner) Stop() {
-	s.doSlow(s.doStop)
+	s.stop(nil)
 }
 
 // StopWith stop the stopper and execute the task.
 // the same as calling Defer(task) first, and then calling Stop().
 func (s *Runner) StopWith(task Task) {
+	s.stop(task)
+}
+
+// stop mark the runner done and close the chan, then stop children and call defers if it's the first stop.
+func (s *Runner) stop(task Task) {
+	var (
+		first  bool
+		defers []Task
+	)
+
 	s.doSlow(func() {
-		s.defers = append(s.defers, task)
-		s.doStop()
+		first = true
+
+		if task != nil {
+			s.defers = append(s.defers, task)
+		}
+
+		defers = s.defers
+
+		// help gc
+		s.defers = nil
+
+		close(s.C)
+		s.cancel()
+		atomic.StoreUint32(&s.done, 1)
 	})
+
+	if first {
+		s.doStop(defers)
+	}
 }
 
 // doSlow do func synchronously if the stopper has not been stopped.
@@ -84,41 +195,118 @@ func (s *Runner) doSlow(f func()) {
 	}
 }
 
+// goTask launch a goroutine running f, which is tracked by name until it returns.
+// Nothing is launched if the runner has been stopped.
+func (s *Runner) goTask(name string, f func()) {
+	s.tm.Lock()
+
+	if s.tasksClosed {
+		s.tm.Unlock()
+		return
+	}
+
+	s.taskID++
+	id := s.taskID
+	s.running[id] = name
+	s.wg.Add(1)
+
+	s.tm.Unlock()
+
+	go func() {
+		defer func() {
+			s.tm.Lock()
+			delete(s.running, id)
+			s.tm.Unlock()
+
+			s.wg.Done()
+		}()
+
+		f()
+	}()
+}
+
+// Go run task in a goroutine tracked by the runner, see StopAndWait.
+// The task is not run if the runner has been stopped, and its panic is recovered and reported, see OnError.
+func (s *Runner) Go(task ContextTask) {
+	name := funcName(task)
+
+	s.goTask(name, func() {
+		if err := s.safeRun(name, wrapContextTask(task)); err != nil {
+			s.reportError(err)
+		}
+	})
+}
+
 // Loop run task util the stopper is stopped.
 // Note, there is not an interval between the executions of two tasks.
+// A panic of the task is recovered, and the task is restarted after a backoff.
 func (s *Runner) Loop(task Task) {
-	go func() {
+	s.loop(s.newTaskRunner(funcName(task), wrapTask(task), nil))
+}
+
+// LoopContext run task util the stopper is stopped, the task should return when the context is canceled.
+// Note, there is not an interval between the executions of two tasks.
+// A panic of the task is recovered, and the task is restarted after a backoff.
+func (s *Runner) LoopContext(task ContextTask) {
+	s.loop(s.newTaskRunner(funcName(task), wrapContextTask(task), nil))
+}
+
+func (s *Runner) loop(t *taskRunner) {
+	s.goTask(t.name, func() {
 		for {
 			select {
 			case <-s.C:
 				return
 			default:
-				task()
+				if !t.run() {
+					return
+				}
 			}
 		}
-	}()
+	})
 }
 
 // Interval run task at intervals util the stopper is stopped.
+// A panic of the task is recovered, and the task is restarted after a backoff.
 func (s *Runner) Interval(task Task, interval time.Duration) {
-	go func() {
+	s.interval(s.newTaskRunner(funcName(task), wrapTask(task), nil), interval)
+}
+
+// IntervalContext run task at intervals util the stopper is stopped,
+// the task should return when the context is canceled.
+// A panic of the task is recovered, and the task is restarted after a backoff.
+func (s *Runner) IntervalContext(task ContextTask, interval time.Duration) {
+	s.interval(s.newTaskRunner(funcName(task), wrapContextTask(task), nil), interval)
+}
+
+func (s *Runner) interval(t *taskRunner, interval time.Duration) {
+	s.goTask(t.name, func() {
 		// run immediately for first time.
 		select {
 		case <-s.C:
 			return
 		default:
-			task()
+			if !t.run() {
+				return
+			}
 		}
 
+		timer := time.NewTimer(interval)
+		defer timer.Stop()
+
 		for {
 			select {
 			case <-s.C:
 				return
-			case <-time.After(interval):
-				task()
+			case <-timer.C:
+				if !t.run() {
+					return
+				}
+
+				timer.Reset(interval)
 			}
 		}
-	}()
+	})
 }
 
 // Handle handle multiple handlers.
@@ -128,20 +316,42 @@ func (s *Runner) Handle(handlers ...func(*Runner)) {
 	}
 }
 
-// New create a new Runner.
-func New() *Runner {
+func newRunner(parent context.Context) *Runner {
+	ctx, cancel := context.WithCancel(parent)
+
 	return &Runner{
-		m: sync.Mutex{},
-		C: make(chan struct{}),
+		m:        sync.Mutex{},
+		C:        make(chan struct{}),
+		stopDone: make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+		children: make(map[*Runner]struct{}),
+		running:  make(map[uint64]string),
 	}
 }
 
+// New create a new Runner.
+func New() *Runner {
+	return newRunner(context.Background())
+}
+
+// FromContext create a new Runner which will be stopped when the context is done.
+// The context of the runner is derived from the given one.
+func FromContext(ctx context.Context) *Runner {
+	r := newRunner(ctx)
+
+	// the callback is also triggered when the runner is stopped and cancels its context,
+	// so it never leaks.
+	context.AfterFunc(r.ctx, r.Stop)
+
+	return r
+}
+
 // NewChild create a new Runner as child of the exists chan, when which is closed the child will be stopped too.
+// A goroutine is started to watch the chan until either the chan is closed or the child is stopped,
+// use Runner.NewChild to avoid it if the parent is a Runner.
 func NewChild(stop chan struct{}) *Runner {
-	child := &Runner{
-		m: sync.Mutex{},
-		C: make(chan struct{}),
-	}
+	child := New()
 
 	go func() {
 		select {
@@ -155,24 +365,18 @@ func NewChild(stop chan struct{}) *Runner {
 }
 
 // NewChild create a new Runner as child of the exists one, when which is stopped the child will be stopped too.
+// The context of the child is derived from the parent's.
 func (s *Runner) NewChild() *Runner {
-	return NewChild(s.C)
+	child := newRunner(s.ctx)
+	s.addChild(child)
+
+	return child
 }
 
 // NewParent create a new Runner as parent of the exists one, which will be stopped when the new parent stopped.
 func (s *Runner) NewParent() *Runner {
-	parent := &Runner{
-		m: sync.Mutex{},
-		C: make(chan struct{}),
-	}
-
-	go func() {
-		select {
-		case <-parent.C:
-			s.Stop()
-		case <-s.C:
-		}
-	}()
+	parent := New()
+	parent.addChild(s)
 
 	return parent
 }
@@ -18,6 +18,8 @@
 package vrun_test
 
 import (
+	"context"
+	"runtime"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -128,6 +130,35 @@ func TestNewParent(t *testing.T) {
 	doTestParentChildRunner(t, s.NewParent(), s)
 }
 
+func TestStopReentrant(t *testing.T) {
+	t.Parallel()
+
+	p := vrun.New()
+	c := p.NewChild()
+
+	// the child stops the parent again while being stopped by it.
+	c.Defer(p.Stop)
+
+	// a defer stops the runner itself.
+	p.Defer(p.Stop)
+
+	stopped := make(chan struct{})
+
+	go func() {
+		p.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("dead lock when stopping the runner again in defers")
+	}
+
+	assert.NoError(t, p.StopAndWait(time.Second))
+	assert.NoError(t, c.StopAndWait(time.Second))
+}
+
 func doTestParentChildRunner(t *testing.T, parent, child *vrun.Runner) {
 	t.Helper()
 
@@ -176,3 +207,146 @@ func TestNewChildFromChan(t *testing.T) {
 
 	assert.Equal(t, int64(1), atomic.LoadInt64(&status1))
 }
+
+func TestContext(t *testing.T) {
+	t.Parallel()
+
+	s := vrun.New()
+	child := s.NewChild()
+
+	var looped int64
+
+	child.LoopContext(func(ctx context.Context) {
+		atomic.AddInt64(&looped, 1)
+		<-ctx.Done()
+	})
+
+	time.Sleep(goroutineScheduleInterval)
+	assert.NoError(t, child.Context().Err())
+
+	s.Stop()
+
+	assert.ErrorIs(t, s.Context().Err(), context.Canceled)
+	assert.ErrorIs(t, child.Context().Err(), context.Canceled)
+
+	time.Sleep(goroutineScheduleInterval)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&looped))
+}
+
+func TestFromContext(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := vrun.FromContext(ctx)
+	child := s.NewChild()
+
+	cancel()
+
+	select {
+	case <-child.C:
+	case <-time.After(time.Second):
+		t.Fatal("runner not stopped after context canceled")
+	}
+
+	select {
+	case <-s.C:
+	default:
+		t.Fatal("runner not stopped after context canceled")
+	}
+
+	// stop runner won't cancel the parent context.
+	ctx = context.Background()
+	s = vrun.FromContext(ctx)
+	s.Stop()
+	assert.NoError(t, ctx.Err())
+}
+
+//nolint:paralleltest // count goroutines.
+func TestNewChildWithoutGoroutine(t *testing.T) {
+	s := vrun.New()
+	before := runtime.NumGoroutine()
+
+	children := make([]*vrun.Runner, 100)
+	for i := range children {
+		children[i] = s.NewChild()
+	}
+
+	assert.Less(t, runtime.NumGoroutine()-before, 10)
+
+	for _, child := range children[:50] {
+		child.Stop()
+	}
+
+	s.Stop()
+
+	for _, child := range children {
+		select {
+		case <-child.C:
+		default:
+			t.Fatal("child not stopped")
+		}
+	}
+
+	// a child of a stopped runner is stopped immediately.
+	child := s.NewChild()
+	select {
+	case <-child.C:
+	default:
+		t.Fatal("child not stopped")
+	}
+}
+
+func TestStopAndWait(t *testing.T) {
+	t.Parallel()
+
+	s := vrun.New()
+
+	var finished int64
+
+	s.LoopContext(func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(goroutineScheduleInterval)
+		atomic.AddInt64(&finished, 1)
+	})
+
+	s.Interval(func() {}, time.Millisecond)
+
+	time.Sleep(goroutineScheduleInterval)
+
+	assert.NoError(t, s.StopAndWait(time.Second))
+	assert.Equal(t, int64(1), atomic.LoadInt64(&finished))
+
+	// no goroutine launched after stopped.
+	s.Go(func(context.Context) {
+		t.Fatal("should not run task after stopped")
+	})
+	assert.NoError(t, s.StopAndWait(time.Second))
+}
+
+func slowDefer() {
+	time.Sleep(goroutineScheduleInterval * 5)
+}
+
+func TestStopAndWaitTimeout(t *testing.T) {
+	t.Parallel()
+
+	s := vrun.New()
+	s.Defer(slowDefer)
+
+	err := s.StopAndWait(goroutineScheduleInterval)
+	assert.ErrorIs(t, err, vrun.ErrStopTimeout)
+
+	var timeoutErr *vrun.StopTimeoutError
+	assert.ErrorAs(t, err, &timeoutErr)
+	assert.Equal(t, "github.com/vogo/vogo/vsync/vrun_test.slowDefer", timeoutErr.Defer)
+
+	s = vrun.New()
+	s.Go(func(context.Context) {
+		time.Sleep(goroutineScheduleInterval * 5)
+	})
+
+	err = s.StopAndWait(goroutineScheduleInterval)
+	assert.ErrorAs(t, err, &timeoutErr)
+	assert.Empty(t, timeoutErr.Defer)
+	assert.Len(t, timeoutErr.Tasks, 1)
+}
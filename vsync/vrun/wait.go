@@ -0,0 +1,122 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vrun
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrStopTimeout the runner failed to stop in time.
+var ErrStopTimeout = errors.New("runner stop timeout")
+
+// StopTimeoutError reports the defer and the goroutines which did not finish in time.
+type StopTimeoutError struct {
+	// Defer the defer being called, empty if all defers finished.
+	Defer string
+	// Tasks the tasks of the goroutines still running.
+	Tasks []string
+}
+
+func (e *StopTimeoutError) Error() string {
+	var b strings.Builder
+
+	b.WriteString(ErrStopTimeout.Error())
+
+	if e.Defer != "" {
+		_, _ = fmt.Fprintf(&b, " | defer: %s", e.Defer)
+	}
+
+	if len(e.Tasks) > 0 {
+		_, _ = fmt.Fprintf(&b, " | tasks: %s", strings.Join(e.Tasks, ", "))
+	}
+
+	return b.String()
+}
+
+func (e *StopTimeoutError) Unwrap() error {
+	return ErrStopTimeout
+}
+
+// funcName returns the name of a func for reporting.
+func funcName(f any) string {
+	if fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer()); fn != nil {
+		return fn.Name()
+	}
+
+	return "unknown"
+}
+
+// StopAndWait stop the runner, and wait for the defers and the goroutines launched by the runner in the timeout.
+// The goroutines of children are not waited, call StopAndWait of them if required.
+// A *StopTimeoutError is returned if any of them does not finish in time.
+func (s *Runner) StopAndWait(timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	stopped := make(chan struct{})
+
+	go func() {
+		s.Stop()
+		<-s.stopDone
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-timer.C:
+		name, _ := s.current.Load().(string)
+
+		return &StopTimeoutError{Defer: name, Tasks: s.runningTasks()}
+	}
+
+	finished := make(chan struct{})
+
+	go func() {
+		s.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-timer.C:
+		return &StopTimeoutError{Tasks: s.runningTasks()}
+	}
+}
+
+// runningTasks returns the names of the tasks of the running goroutines.
+func (s *Runner) runningTasks() []string {
+	s.tm.Lock()
+	defer s.tm.Unlock()
+
+	var names []string
+
+	for _, name := range s.running {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -19,6 +19,8 @@
 
 package vsync
 
+import "sync"
+
 // IsChanClosed checks if a channel is closed.
 func IsChanClosed(c chan struct{}) bool {
 	if c == nil {
@@ -32,13 +34,110 @@ func IsChanClosed(c chan struct{}) bool {
 	}
 }
 
+// IsClosed checks if a receive-only signal channel is closed, e.g. ctx.Done(), a nil channel is treated as closed.
+// Like IsChanClosed, a value sent to the channel is received by the check, so only use it for channels never sent to.
+func IsClosed(c <-chan struct{}) bool {
+	if c == nil {
+		return true
+	}
+
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
 // SafeClosed closes a channel safely.
+//
+// Deprecated: use SafeClose instead.
 func SafeClosed(c chan struct{}) {
-	if !IsChanClosed(c) {
-		defer func() {
-			_ = recover()
-		}()
+	SafeClose(c)
+}
+
+// SafeClose closes a channel without panic if it's already closed, returns whether it's closed by this call.
+// Prefer OnceChan to avoid closing a closed channel.
+func SafeClose[T any](c chan T) (closed bool) {
+	defer func() {
+		if recover() != nil {
+			closed = false
+		}
+	}()
+
+	close(c)
+
+	return true
+}
+
+// OnceChan a channel which can be closed multiple times safely.
+type OnceChan[T any] struct {
+	C chan T
+
+	once   sync.Once
+	closed chan struct{}
+
+	// mu guards the sending of Send against closing C.
+	mu sync.RWMutex
+}
+
+// NewOnceChan create a OnceChan with a channel of the buffer size.
+func NewOnceChan[T any](size int) *OnceChan[T] {
+	return &OnceChan[T]{
+		C:      make(chan T, size),
+		closed: make(chan struct{}),
+	}
+}
 
-		close(c)
+// Close close the channel, returns whether it's closed by this call.
+// The channel must not be sent to after closed, use Send for the senders racing with Close.
+func (c *OnceChan[T]) Close() bool {
+	closed := false
+
+	c.once.Do(func() {
+		// release the blocked senders first.
+		close(c.closed)
+
+		c.mu.Lock()
+		close(c.C)
+		c.mu.Unlock()
+
+		closed = true
+	})
+
+	return closed
+}
+
+// Send send v to the channel, blocks until it's sent or the channel is closed, returns whether it's sent.
+func (c *OnceChan[T]) Send(v T) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	select {
+	case <-c.closed:
+		return false
+	default:
+	}
+
+	select {
+	case c.C <- v:
+		return true
+	case <-c.closed:
+		return false
+	}
+}
+
+// Done returns a channel closed when the OnceChan is closed, which is never sent to.
+func (c *OnceChan[T]) Done() <-chan struct{} {
+	return c.closed
+}
+
+// IsClosed returns whether the channel is closed.
+func (c *OnceChan[T]) IsClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
 	}
 }
@@ -0,0 +1,78 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vcron_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/vtime/vcron"
+)
+
+func TestNext(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2024, 2, 28, 10, 3, 20, 500, time.UTC) // wednesday
+
+	tests := []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * * *", time.Date(2024, 2, 28, 10, 3, 21, 0, time.UTC)},
+		{"0 */5 * * * *", time.Date(2024, 2, 28, 10, 5, 0, 0, time.UTC)},
+		{"*/5 * * * *", time.Date(2024, 2, 28, 10, 5, 0, 0, time.UTC)},
+		{"30 9 * * *", time.Date(2024, 2, 29, 9, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * MON-FRI", time.Date(2024, 2, 28, 12, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 5-7", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 6-7", time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * MON-7", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * */2", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 1/2", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * SUN-SAT", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * FRI-SAT", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * JAN,jun *", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 15 * 1", time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)}, // day of month or day of week
+		{"10-20/5 3 10 * * ?", time.Date(2024, 2, 29, 10, 3, 10, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 2, 28, 11, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		s, err := vcron.Parse(tt.spec)
+		assert.NoError(t, err, tt.spec)
+		assert.Equal(t, tt.next, s.Next(from), tt.spec)
+	}
+
+	loc := time.FixedZone("UTC+8", 8*3600)
+	s := vcron.MustParse("0 0 * * *")
+	assert.Equal(t, time.Date(2024, 2, 29, 0, 0, 0, 0, loc), s.Next(from.In(loc)))
+}
+
+func TestParseError(t *testing.T) {
+	t.Parallel()
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := vcron.Parse(spec)
+		assert.ErrorIs(t, err, vcron.ErrInvalidSpec, spec)
+	}
+}
@@ -0,0 +1,281 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vrate
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// MinBurst the min burst of a token bucket, a smaller burst never allows any event.
+	MinBurst = 1
+	// DefaultWindow the window of a sliding window limiter if the given one is not positive.
+	DefaultWindow = time.Second
+)
+
+// Limiter limits the rate of events.
+type Limiter interface {
+	// Allow reports whether an event may happen now.
+	Allow() bool
+
+	// Reserve reports whether an event may happen at the given time,
+	// if not, it also returns the duration to wait before retrying.
+	Reserve(now time.Time) (bool, time.Duration)
+}
+
+// Wait blocks until the limiter permits an event or the context is done.
+func Wait(ctx context.Context, l Limiter) error {
+	for {
+		ok, retryAfter := l.Reserve(time.Now())
+		if ok {
+			return nil
+		}
+
+		timer := time.NewTimer(retryAfter)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// TokenBucket a limiter which refills tokens at a fixed rate, and allows bursts up to the bucket size.
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket create a token bucket limiter, which allows rate events per second and bursts up to burst events.
+// The bucket is full initially. A burst less than MinBurst is raised to MinBurst.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < MinBurst {
+		burst = MinBurst
+	}
+
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+func (b *TokenBucket) Allow() bool {
+	ok, _ := b.Reserve(time.Now())
+
+	return ok
+}
+
+func (b *TokenBucket) Reserve(now time.Time) (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.last.IsZero() && now.After(b.last) {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+
+	if now.After(b.last) {
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	if b.rate <= 0 {
+		return false, time.Duration(1<<63 - 1)
+	}
+
+	return false, time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// minRetryAfter the minimum duration to wait for a sliding window limiter.
+const minRetryAfter = time.Millisecond
+
+// SlidingWindow a limiter which allows at most limit events in any window,
+// the count of the sliding window is estimated from the counts of the current and the previous fixed windows.
+type SlidingWindow struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	start  time.Time // start time of the current fixed window
+	curr   int
+	prev   int
+}
+
+// NewSlidingWindow create a sliding window limiter which allows at most limit events in a window,
+// DefaultWindow is used if window is not positive.
+func NewSlidingWindow(limit int, window time.Duration) *SlidingWindow {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+
+	return &SlidingWindow{
+		limit:  limit,
+		window: window,
+	}
+}
+
+func (w *SlidingWindow) Allow() bool {
+	ok, _ := w.Reserve(time.Now())
+
+	return ok
+}
+
+func (w *SlidingWindow) Reserve(now time.Time) (bool, time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	start := now.Truncate(w.window)
+
+	switch {
+	case w.start.IsZero() || start.Sub(w.start) > w.window:
+		w.prev, w.curr = 0, 0
+	case start.After(w.start):
+		w.prev, w.curr = w.curr, 0
+	}
+
+	if start.After(w.start) {
+		w.start = start
+	}
+
+	elapsed := now.Sub(w.start)
+	weight := 1 - float64(elapsed)/float64(w.window)
+
+	if float64(w.prev)*weight+float64(w.curr) < float64(w.limit) {
+		w.curr++
+		return true, 0
+	}
+
+	retryAfter := w.window - elapsed
+
+	// the estimated count decreases as the previous window slides out.
+	if w.prev > 0 && w.curr < w.limit {
+		d := time.Duration((1-float64(w.limit-w.curr)/float64(w.prev))*float64(w.window)) - elapsed
+		if d < retryAfter {
+			retryAfter = max(d, minRetryAfter)
+		}
+	}
+
+	return false, retryAfter
+}
+
+type keyedEntry struct {
+	limiter  Limiter
+	lastSeen time.Time
+}
+
+// KeyedLimiter maintains a limiter for each key, e.g. a client ip or a user id.
+// Limiters which are idle longer than the idle timeout are evicted.
+type KeyedLimiter[K comparable] struct {
+	mu          sync.Mutex
+	newLimiter  func() Limiter
+	idleTimeout time.Duration
+	lastEvict   time.Time
+	entries     map[K]*keyedEntry
+}
+
+// NewKeyedLimiter create a keyed limiter, the newLimiter func is called to create the limiter for a new key.
+func NewKeyedLimiter[K comparable](newLimiter func() Limiter, idleTimeout time.Duration) *KeyedLimiter[K] {
+	return &KeyedLimiter[K]{
+		newLimiter:  newLimiter,
+		idleTimeout: idleTimeout,
+		lastEvict:   time.Now(),
+		entries:     make(map[K]*keyedEntry),
+	}
+}
+
+// Allow reports whether an event of the key may happen now.
+func (k *KeyedLimiter[K]) Allow(key K) bool {
+	ok, _ := k.Reserve(key, time.Now())
+
+	return ok
+}
+
+// Reserve reports whether an event of the key may happen at the given time,
+// if not, it also returns the duration to wait before retrying.
+func (k *KeyedLimiter[K]) Reserve(key K, now time.Time) (bool, time.Duration) {
+	return k.get(key, now).Reserve(now)
+}
+
+// Wait blocks until the limiter of the key permits an event or the context is done.
+func (k *KeyedLimiter[K]) Wait(ctx context.Context, key K) error {
+	return Wait(ctx, k.get(key, time.Now()))
+}
+
+func (k *KeyedLimiter[K]) get(key K, now time.Time) Limiter {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	// evict lazily, at most once per idle timeout.
+	if k.idleTimeout > 0 && now.Sub(k.lastEvict) >= k.idleTimeout {
+		k.evict(now)
+	}
+
+	entry, ok := k.entries[key]
+	if !ok {
+		entry = &keyedEntry{limiter: k.newLimiter()}
+		k.entries[key] = entry
+	}
+
+	entry.lastSeen = now
+
+	return entry.limiter
+}
+
+// Evict remove limiters which are idle longer than the idle timeout, returns the count of evicted ones.
+func (k *KeyedLimiter[K]) Evict() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.evict(time.Now())
+}
+
+func (k *KeyedLimiter[K]) evict(now time.Time) int {
+	count := 0
+
+	for key, entry := range k.entries {
+		if now.Sub(entry.lastSeen) >= k.idleTimeout {
+			delete(k.entries, key)
+			count++
+		}
+	}
+
+	k.lastEvict = now
+
+	return count
+}
+
+// Len returns the count of keys.
+func (k *KeyedLimiter[K]) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return len(k.entries)
+}
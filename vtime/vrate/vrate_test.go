@@ -0,0 +1,144 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vrate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vogo/vogo/vtime/vrate"
+)
+
+func TestTokenBucket(t *testing.T) {
+	t.Parallel()
+
+	b := vrate.NewTokenBucket(10, 2)
+	now := time.Now()
+
+	ok, _ := b.Reserve(now)
+	assert.True(t, ok)
+	ok, _ = b.Reserve(now)
+	assert.True(t, ok)
+
+	ok, retryAfter := b.Reserve(now)
+	assert.False(t, ok)
+	assert.Equal(t, 100*time.Millisecond, retryAfter)
+
+	ok, _ = b.Reserve(now.Add(100 * time.Millisecond))
+	assert.True(t, ok)
+
+	// refill no more than burst
+	now = now.Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		ok, _ = b.Reserve(now)
+		assert.True(t, ok)
+	}
+
+	ok, _ = b.Reserve(now)
+	assert.False(t, ok)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	t.Parallel()
+
+	w := vrate.NewSlidingWindow(4, time.Second)
+	start := time.Now().Truncate(time.Second)
+
+	for i := 0; i < 4; i++ {
+		ok, _ := w.Reserve(start)
+		assert.True(t, ok)
+	}
+
+	ok, retryAfter := w.Reserve(start.Add(500 * time.Millisecond))
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	// half of the previous window counts.
+	now := start.Add(1500 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		ok, _ = w.Reserve(now)
+		assert.True(t, ok)
+	}
+
+	ok, retryAfter = w.Reserve(now)
+	assert.False(t, ok)
+	assert.Equal(t, time.Millisecond, retryAfter)
+
+	// the previous windows slide out.
+	ok, _ = w.Reserve(start.Add(3 * time.Second))
+	assert.True(t, ok)
+}
+
+func TestTokenBucketMinBurst(t *testing.T) {
+	t.Parallel()
+
+	b := vrate.NewTokenBucket(1, 0)
+	now := time.Now()
+
+	ok, _ := b.Reserve(now)
+	assert.True(t, ok)
+
+	ok, retryAfter := b.Reserve(now)
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, retryAfter)
+
+	ok, _ = b.Reserve(now.Add(time.Second))
+	assert.True(t, ok)
+}
+
+func TestSlidingWindowDefaultWindow(t *testing.T) {
+	t.Parallel()
+
+	for _, window := range []time.Duration{0, -time.Second} {
+		w := vrate.NewSlidingWindow(1, window)
+		start := time.Now().Truncate(vrate.DefaultWindow)
+
+		ok, _ := w.Reserve(start)
+		assert.True(t, ok)
+
+		ok, retryAfter := w.Reserve(start)
+		assert.False(t, ok)
+		assert.Equal(t, vrate.DefaultWindow, retryAfter)
+	}
+}
+
+func TestKeyedLimiter(t *testing.T) {
+	t.Parallel()
+
+	k := vrate.NewKeyedLimiter[string](func() vrate.Limiter {
+		return vrate.NewTokenBucket(1, 1)
+	}, 20*time.Millisecond)
+
+	assert.True(t, k.Allow("a"))
+	assert.False(t, k.Allow("a"))
+	assert.True(t, k.Allow("b"))
+	assert.Equal(t, 2, k.Len())
+
+	time.Sleep(30 * time.Millisecond)
+
+	assert.Equal(t, 2, k.Evict())
+	assert.Equal(t, 0, k.Len())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.NoError(t, k.Wait(ctx, "a"))
+	assert.ErrorIs(t, k.Wait(ctx, "a"), context.DeadlineExceeded)
+}